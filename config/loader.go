@@ -0,0 +1,274 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	customErrors "github.com/aryadiwwt/synctodb/errors"
+)
+
+// EnvConfigFile adalah environment variable yang berisi path file konfigurasi
+// jika flag -config tidak diberikan.
+const EnvConfigFile = "SYNCTODB_CONFIG"
+
+// Overrides menampung nilai flag -set key=value. Mengimplementasikan
+// flag.Value sehingga flag bisa diulang.
+type Overrides []string
+
+func (o *Overrides) String() string { return strings.Join(*o, ",") }
+
+// Set mengimplementasikan flag.Value.
+func (o *Overrides) Set(v string) error {
+	if !strings.Contains(v, "=") {
+		return fmt.Errorf("override %q harus berbentuk key=value", v)
+	}
+	*o = append(*o, v)
+	return nil
+}
+
+// Load membangun konfigurasi efektif dari default, file konfigurasi (boleh
+// kosong), environment variable dan overrides (key=value), lalu memvalidasinya.
+//
+// Jika file tidak bisa dibaca, error dikembalikan tanpa Config. Jika yang
+// bermasalah hanya nilainya, Config tetap dikembalikan bersama
+// *errors.ErrInvalidConfig yang berisi semua masalah sekaligus.
+func Load(path string, overrides []string) (*Config, error) {
+	cfg := Default()
+
+	if path == "" {
+		path = os.Getenv(EnvConfigFile)
+	}
+	if path != "" {
+		if err := loadFile(cfg, path); err != nil {
+			return nil, err
+		}
+	}
+
+	// explicit mencatat key yang diisi lewat env atau flag, agar nilai dari
+	// file rahasia tidak menimpanya.
+	explicit := make(map[string]bool)
+	var problems []string
+	problems = append(problems, applyEnv(cfg, explicit)...)
+	problems = append(problems, applyOverrides(cfg, overrides, explicit)...)
+	problems = append(problems, applySecretsFile(cfg, explicit)...)
+	problems = append(problems, cfg.validate()...)
+
+	if len(problems) > 0 {
+		return cfg, &customErrors.ErrInvalidConfig{Problems: problems}
+	}
+	return cfg, nil
+}
+
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("gagal membaca file konfigurasi: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && err != io.EOF {
+			return fmt.Errorf("gagal membaca YAML %s: %w", path, err)
+		}
+	case ".toml":
+		md, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return fmt.Errorf("gagal membaca TOML %s: %w", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("key tidak dikenal di %s: %v", path, undecoded)
+		}
+	default:
+		return fmt.Errorf("format file konfigurasi %q tidak didukung (gunakan .yaml, .yml atau .toml)", path)
+	}
+	return nil
+}
+
+// field adalah satu nilai konfigurasi hasil penelusuran struct Config.
+type field struct {
+	key   string // key bertitik sesuai nama di file, misal "api.url"
+	tag   reflect.StructTag
+	value reflect.Value
+}
+
+// fields menelusuri semua field daun di Config secara berurutan.
+func fields(cfg *Config) []field {
+	var out []field
+	var walk func(v reflect.Value, prefix string)
+	walk = func(v reflect.Value, prefix string) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			name, _, _ := strings.Cut(sf.Tag.Get("yaml"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			key := prefix + name
+			fv := v.Field(i)
+			if fv.Kind() == reflect.Struct {
+				walk(fv, key+".")
+				continue
+			}
+			out = append(out, field{key: key, tag: sf.Tag, value: fv})
+		}
+	}
+	walk(reflect.ValueOf(cfg).Elem(), "")
+	return out
+}
+
+// applyEnv mengisi field dari environment variable. Untuk setiap NAMA juga
+// didukung NAMA_FILE yang berisi path file (Docker/Kubernetes secrets);
+// isi file dipakai sebagai nilai setelah spasi/newline di ujungnya dibuang.
+func applyEnv(cfg *Config, explicit map[string]bool) []string {
+	var problems []string
+	for _, f := range fields(cfg) {
+		name := f.tag.Get("env")
+		if name == "" {
+			continue
+		}
+
+		raw, ok, err := LookupEnv(name)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		if err := setValue(f.value, raw); err != nil {
+			problems = append(problems, fmt.Sprintf("env %s: %v", name, err))
+			continue
+		}
+		explicit[f.key] = true
+	}
+	return problems
+}
+
+// LookupEnv membaca environment variable name, atau isi file yang ditunjuk
+// name_FILE tanpa newline di ujungnya. ok false berarti keduanya tidak diisi.
+func LookupEnv(name string) (value string, ok bool, err error) {
+	value, ok = os.LookupEnv(name)
+	filePath, fromFile := os.LookupEnv(name + "_FILE")
+	switch {
+	case ok && fromFile:
+		return "", false, fmt.Errorf("env %s dan %s_FILE tidak boleh diisi bersamaan", name, name)
+	case fromFile:
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return "", false, fmt.Errorf("env %s_FILE: gagal membaca file: %v", name, err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), true, nil
+	}
+	return value, ok, nil
+}
+
+func applyOverrides(cfg *Config, overrides []string, explicit map[string]bool) []string {
+	byKey := fieldsByKey(cfg)
+
+	var problems []string
+	for _, o := range overrides {
+		key, raw, _ := strings.Cut(o, "=")
+		key = strings.TrimSpace(key)
+		f, ok := byKey[key]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("flag -set %s: key tidak dikenal", key))
+			continue
+		}
+		if err := setValue(f.value, raw); err != nil {
+			problems = append(problems, fmt.Sprintf("flag -set %s: %v", key, err))
+			continue
+		}
+		explicit[key] = true
+	}
+	return problems
+}
+
+func fieldsByKey(cfg *Config) map[string]field {
+	byKey := make(map[string]field)
+	for _, f := range fields(cfg) {
+		byKey[f.key] = f
+	}
+	return byKey
+}
+
+// setValue mengisi field dari string mentah (env atau flag).
+func setValue(v reflect.Value, raw string) error {
+	raw = strings.TrimSpace(raw)
+
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("durasi %q tidak valid", raw)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q bukan bilangan bulat", raw)
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("%q bukan angka", raw)
+		}
+		v.SetFloat(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q bukan boolean", raw)
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("tipe %s tidak didukung", v.Type())
+		}
+		v.Set(reflect.ValueOf(splitList(raw)))
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String || v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("tipe %s tidak didukung", v.Type())
+		}
+		m := make(map[string]string)
+		for _, item := range splitList(raw) {
+			k, val, ok := strings.Cut(item, "=")
+			if !ok {
+				return fmt.Errorf("%q harus berbentuk key=value", item)
+			}
+			m[strings.TrimSpace(k)] = strings.TrimSpace(val)
+		}
+		v.Set(reflect.ValueOf(m))
+	default:
+		return fmt.Errorf("tipe %s tidak didukung", v.Type())
+	}
+	return nil
+}
+
+// splitList memecah daftar yang dipisahkan koma, mengabaikan item kosong.
+func splitList(raw string) []string {
+	var out []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,304 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	customErrors "github.com/aryadiwwt/synctodb/errors"
+)
+
+// isolateEnv mengosongkan semua environment variable konfigurasi (termasuk
+// varian _FILE) selama test, agar environment mesin tidak ikut terbaca.
+func isolateEnv(t *testing.T) {
+	t.Helper()
+	names := []string{EnvConfigFile}
+	for _, f := range fields(Default()) {
+		if name := f.tag.Get("env"); name != "" {
+			names = append(names, name, name+"_FILE")
+		}
+	}
+	for _, name := range names {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// writeSecrets mengenkripsi values (YAML) dan mengembalikan path file serta
+// kuncinya.
+func writeSecrets(t *testing.T, values string) (path, key string) {
+	t.Helper()
+	key, err := GenerateSecretsKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := EncryptSecrets([]byte(values), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writeFile(t, "secrets.enc", string(data)), key
+}
+
+const minimalYAML = `
+api:
+  username: user
+  password: rahasia
+`
+
+func TestLoadPrecedence(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string // isi sync.delay di file; kosong berarti tidak diisi
+		secrets  string
+		env      string
+		envFile  string
+		override string
+		want     time.Duration
+	}{
+		{name: "default", want: 0},
+		{name: "file menimpa default", file: "1s", want: time.Second},
+		{name: "file rahasia menimpa file", file: "1s", secrets: "2s", want: 2 * time.Second},
+		{name: "env menimpa file rahasia", file: "1s", secrets: "2s", env: "3s", want: 3 * time.Second},
+		{name: "env _FILE menimpa file rahasia", file: "1s", secrets: "2s", envFile: "4s\n", want: 4 * time.Second},
+		{name: "flag menimpa env", file: "1s", secrets: "2s", env: "3s", override: "5s", want: 5 * time.Second},
+		{name: "flag menimpa file rahasia", secrets: "2s", override: "5s", want: 5 * time.Second},
+		{name: "file rahasia tanpa file", secrets: "2s", want: 2 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolateEnv(t)
+
+			content := minimalYAML
+			if tt.file != "" {
+				content += "sync:\n  delay: " + tt.file + "\n"
+			}
+			path := writeFile(t, "config.yaml", content)
+			if tt.secrets != "" {
+				secrets, key := writeSecrets(t, "sync.delay: "+tt.secrets+"\n")
+				t.Setenv("SYNCTODB_SECRETS_FILE", secrets)
+				t.Setenv("SYNCTODB_SECRETS_KEY", key)
+			}
+			if tt.env != "" {
+				t.Setenv("SYNC_DELAY", tt.env)
+			}
+			if tt.envFile != "" {
+				t.Setenv("SYNC_DELAY_FILE", writeFile(t, "delay", tt.envFile))
+			}
+			var overrides []string
+			if tt.override != "" {
+				overrides = append(overrides, "sync.delay="+tt.override)
+			}
+
+			cfg, err := Load(path, overrides)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.Sync.Delay != tt.want {
+				t.Errorf("sync.delay = %s, want %s", cfg.Sync.Delay, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFileFromEnv(t *testing.T) {
+	isolateEnv(t)
+	t.Setenv(EnvConfigFile, writeFile(t, "config.yaml", minimalYAML+"sync:\n  delay: 7s\n"))
+	cfg, err := Load("", nil)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Sync.Delay != 7*time.Second {
+		t.Errorf("sync.delay = %s, want 7s", cfg.Sync.Delay)
+	}
+}
+
+func TestLoadYAMLTOMLParity(t *testing.T) {
+	const yamlConfig = `
+api:
+  username: user
+  password: rahasia
+  tahun: 2024
+  headers:
+    X-Api-Key: kunci
+database:
+  max_open_conns: 20
+  auto_migrate: true
+http:
+  timeout: 30s
+  rate_limit:
+    rate: 2
+    max_rate: 4
+sync:
+  provinsi: ["51", "52"]
+  delay: 1m30s
+transform:
+  mappings:
+    kode_sumber: sumber
+`
+	const tomlConfig = `
+[api]
+username = "user"
+password = "rahasia"
+tahun = 2024
+
+[api.headers]
+X-Api-Key = "kunci"
+
+[database]
+max_open_conns = 20
+auto_migrate = true
+
+[http]
+timeout = "30s"
+
+[http.rate_limit]
+rate = 2.0
+max_rate = 4.0
+
+[sync]
+provinsi = ["51", "52"]
+delay = "1m30s"
+
+[transform.mappings]
+kode_sumber = "sumber"
+`
+	isolateEnv(t)
+	fromYAML, err := Load(writeFile(t, "config.yaml", yamlConfig), nil)
+	if err != nil {
+		t.Fatalf("YAML: %v", err)
+	}
+	fromTOML, err := Load(writeFile(t, "config.toml", tomlConfig), nil)
+	if err != nil {
+		t.Fatalf("TOML: %v", err)
+	}
+	if !reflect.DeepEqual(fromYAML, fromTOML) {
+		t.Errorf("hasil YAML dan TOML berbeda:\nYAML: %+v\nTOML: %+v", fromYAML, fromTOML)
+	}
+	if fromYAML.Sync.Delay != 90*time.Second || fromYAML.HTTP.RateLimit.Rate != 2 || fromYAML.API.Headers["X-Api-Key"] != "kunci" {
+		t.Errorf("nilai dari file tidak terbaca: %+v", fromYAML)
+	}
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr string
+	}{
+		{name: "key YAML tidak dikenal", file: "config.yaml", content: "api:\n  usernam: x\n", wantErr: "usernam"},
+		{name: "key TOML tidak dikenal", file: "config.toml", content: "[api]\nusernam = \"x\"\n", wantErr: "key tidak dikenal"},
+		{name: "format tidak didukung", file: "config.json", content: "{}", wantErr: "tidak didukung"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolateEnv(t)
+			cfg, err := Load(writeFile(t, tt.file, tt.content), nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Load() error = %v, want %q", err, tt.wantErr)
+			}
+			if cfg != nil {
+				t.Error("file yang tidak bisa dibaca tidak boleh mengembalikan Config")
+			}
+		})
+	}
+}
+
+func TestLoadCollectsAllProblems(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		env       map[string]string
+		overrides []string
+		want      []string
+	}{
+		{
+			name:    "kredensial kosong",
+			content: "sync:\n  delay: 1s\n",
+			want:    []string{"api.username: wajib diisi", "api.password: wajib diisi"},
+		},
+		{
+			name: "banyak nilai salah di file",
+			content: minimalYAML + `  tahun: 1990
+  request_style: put
+http:
+  page_concurrency: 0
+  page_timeout: 1s
+sync:
+  provinsi: ["5103"]
+storer:
+  sinks: [parquet, s3]
+wilayah:
+  table: "master kota"
+`,
+			want: []string{
+				"api.tahun: 1990 di luar rentang 2000-2100",
+				`api.request_style: "put" tidak dikenal`,
+				"http.page_concurrency: minimal 1",
+				"http.timeout: 2m0s melebihi http.page_timeout 1s",
+				`sync.provinsi: "5103" bukan kode provinsi`,
+				`wilayah.table: "master kota" bukan nama tabel/kolom yang valid`,
+				`storer.sinks: "s3" tidak dikenal`,
+			},
+		},
+		{
+			name:      "env, flag dan validasi dikumpulkan bersama",
+			content:   minimalYAML,
+			env:       map[string]string{"SYNC_DELAY": "sebentar", "DB_MAX_OPEN_CONNS": "-1"},
+			overrides: []string{"sync.tidak_ada=1", "http.timeout=abc"},
+			want: []string{
+				`env SYNC_DELAY: durasi "sebentar" tidak valid`,
+				"flag -set sync.tidak_ada: key tidak dikenal",
+				`flag -set http.timeout: durasi "abc" tidak valid`,
+				"database.max_open_conns: tidak boleh negatif",
+			},
+		},
+		{
+			name:    "env dan _FILE bersamaan",
+			content: minimalYAML,
+			env:     map[string]string{"API_PASSWORD": "a", "API_PASSWORD_FILE": "/tidak/ada"},
+			want:    []string{"env API_PASSWORD dan API_PASSWORD_FILE tidak boleh diisi bersamaan"},
+		},
+		{
+			name:    "file rahasia tanpa kunci",
+			content: minimalYAML,
+			env:     map[string]string{"SYNCTODB_SECRETS_FILE": "/tidak/ada"},
+			want:    []string{"secrets.key: wajib diisi jika secrets.file digunakan"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolateEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			cfg, err := Load(writeFile(t, "config.yaml", tt.content), tt.overrides)
+			var invalid *customErrors.ErrInvalidConfig
+			if !errors.As(err, &invalid) {
+				t.Fatalf("Load() error = %v, want ErrInvalidConfig", err)
+			}
+			if cfg == nil {
+				t.Error("Config tetap harus dikembalikan bersama ErrInvalidConfig")
+			}
+			got := strings.Join(invalid.Problems, "\n")
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("masalah %q tidak dilaporkan; semua masalah:\n%s", want, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// redactedValue menggantikan nilai rahasia saat konfigurasi ditampilkan.
+const redactedValue = "[REDACTED]"
+
+// Redacted mengembalikan salinan konfigurasi dengan semua field bertanda
+// `secret` disamarkan. Untuk secret:"dsn" hanya password di dalam URL yang
+// disamarkan (menjadi "xxxxx") agar host dan nama database tetap terlihat.
+// Untuk secret:"values" (map) key tetap terlihat dan setiap nilai disamarkan.
+func (c *Config) Redacted() *Config {
+	cp := *c
+
+	for _, f := range fields(&cp) {
+		if f.tag.Get("secret") == "values" {
+			f.value.Set(reflect.ValueOf(redactValues(f.value.Interface().(map[string]string))))
+			continue
+		}
+		if f.value.String() == "" {
+			continue
+		}
+		switch f.tag.Get("secret") {
+		case "true":
+			f.value.SetString(redactedValue)
+		case "dsn":
+			f.value.SetString(redactDSN(f.value.String()))
+		}
+	}
+	return &cp
+}
+
+// redactValues menyalin m dengan setiap nilai disamarkan; map asli milik
+// konfigurasi yang sedang dipakai tidak diubah.
+func redactValues(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k := range m {
+		out[k] = redactedValue
+	}
+	return out
+}
+
+// redactDSN menyamarkan password di URL koneksi. Jika URL tidak bisa
+// di-parse, seluruh nilai disamarkan.
+func redactDSN(dsn string) string {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return redactedValue
+	}
+	return u.Redacted()
+}
+
+// Write menulis konfigurasi dalam format "yaml" atau "toml".
+func (c *Config) Write(w io.Writer, format string) error {
+	switch format {
+	case "yaml", "yml", "":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(c); err != nil {
+			return fmt.Errorf("gagal menulis YAML: %w", err)
+		}
+		return enc.Close()
+	case "toml":
+		if err := toml.NewEncoder(w).Encode(c); err != nil {
+			return fmt.Errorf("gagal menulis TOML: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("format %q tidak didukung (gunakan yaml atau toml)", format)
+	}
+}
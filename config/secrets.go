@@ -0,0 +1,167 @@
+package config
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// secretsHeader menandai file rahasia terenkripsi beserta versinya.
+const secretsHeader = "synctodb-secrets-v1\n"
+
+// GenerateSecretsKey membuat kunci AES-256 acak dalam bentuk base64.
+func GenerateSecretsKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("gagal membuat kunci: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// EncryptSecrets mengenkripsi isi file rahasia (YAML berisi pasangan key
+// konfigurasi dan nilainya, misal "api.password: rahasia") dengan AES-256-GCM.
+func EncryptSecrets(plaintext []byte, key string) ([]byte, error) {
+	// Pastikan isinya bisa dibaca sebelum dienkripsi.
+	var values map[string]string
+	if err := yaml.Unmarshal(plaintext, &values); err != nil {
+		return nil, fmt.Errorf("isi file rahasia harus YAML key: value: %w", err)
+	}
+
+	gcm, err := secretsCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("gagal membuat nonce: %w", err)
+	}
+
+	sealed := gcm.Seal(nonce, nonce, plaintext, []byte(secretsHeader))
+	return []byte(secretsHeader + base64.StdEncoding.EncodeToString(sealed) + "\n"), nil
+}
+
+func decryptSecrets(data []byte, key string) (map[string]string, error) {
+	if !bytes.HasPrefix(data, []byte(secretsHeader)) {
+		return nil, fmt.Errorf("bukan file rahasia synctodb (header tidak dikenali)")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data[len(secretsHeader):])))
+	if err != nil {
+		return nil, fmt.Errorf("isi file rahasia rusak: %w", err)
+	}
+
+	gcm, err := secretsCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("isi file rahasia terlalu pendek")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, []byte(secretsHeader))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mendekripsi file rahasia (kunci salah?)")
+	}
+
+	var values map[string]string
+	if err := yaml.Unmarshal(plaintext, &values); err != nil {
+		return nil, fmt.Errorf("isi file rahasia bukan YAML yang valid")
+	}
+	return values, nil
+}
+
+func secretsCipher(key string) (cipher.AEAD, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key))
+	if err != nil || len(raw) != 32 {
+		return nil, fmt.Errorf("kunci rahasia harus 32 byte dalam base64")
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// applySecretsFile mengisi field dari file rahasia terenkripsi, kecuali
+// field yang sudah diisi lewat env atau flag.
+func applySecretsFile(cfg *Config, explicit map[string]bool) []string {
+	if cfg.Secrets.File == "" {
+		return nil
+	}
+	if cfg.Secrets.Key == "" {
+		return []string{"secrets.key: wajib diisi jika secrets.file digunakan"}
+	}
+
+	data, err := os.ReadFile(cfg.Secrets.File)
+	if err != nil {
+		return []string{fmt.Sprintf("secrets.file: gagal membaca file: %v", err)}
+	}
+	values, err := decryptSecrets(data, cfg.Secrets.Key)
+	if err != nil {
+		return []string{fmt.Sprintf("secrets.file: %v", err)}
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	byKey := fieldsByKey(cfg)
+	var problems []string
+	for _, key := range keys {
+		raw := values[key]
+		f, ok := byKey[key]
+		if !ok || strings.HasPrefix(key, "secrets.") {
+			problems = append(problems, fmt.Sprintf("secrets.file: key %q tidak dikenal", key))
+			continue
+		}
+		if explicit[key] {
+			continue
+		}
+		if err := setValue(f.value, raw); err != nil {
+			problems = append(problems, fmt.Sprintf("secrets.file %s: %v", key, err))
+		}
+	}
+	return problems
+}
+
+// SecretValues mengembalikan semua nilai rahasia di konfigurasi (termasuk
+// password di dalam DSN dan nilai header API) untuk didaftarkan ke
+// redact.Redactor.
+func (c *Config) SecretValues() []string {
+	var out []string
+	for _, f := range fields(c) {
+		if f.tag.Get("secret") == "values" {
+			for _, v := range f.value.Interface().(map[string]string) {
+				if v != "" {
+					out = append(out, v)
+				}
+			}
+			continue
+		}
+		value := f.value.String()
+		if value == "" {
+			continue
+		}
+		switch f.tag.Get("secret") {
+		case "true":
+			out = append(out, value)
+		case "dsn":
+			if u, err := url.Parse(value); err == nil {
+				if pass, ok := u.User.Password(); ok {
+					out = append(out, pass)
+				}
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aryadiwwt/synctodb/redact"
+)
+
+func TestLookupEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   *string
+		file    *string
+		want    string
+		wantOK  bool
+		wantErr string
+	}{
+		{name: "tidak diisi"},
+		{name: "env biasa", value: ptr("rahasia"), want: "rahasia", wantOK: true},
+		{name: "_FILE dengan newline", file: ptr("rahasia\n"), want: "rahasia", wantOK: true},
+		{name: "_FILE dengan CRLF", file: ptr("rahasia\r\n"), want: "rahasia", wantOK: true},
+		{name: "_FILE dengan beberapa newline", file: ptr("rahasia\n\n"), want: "rahasia", wantOK: true},
+		{name: "_FILE tanpa newline", file: ptr("rahasia"), want: "rahasia", wantOK: true},
+		{name: "_FILE mempertahankan spasi di tengah", file: ptr("pass word\n"), want: "pass word", wantOK: true},
+		{name: "keduanya diisi", value: ptr("a"), file: ptr("b"), wantErr: "tidak boleh diisi bersamaan"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolateEnv(t)
+			if tt.value != nil {
+				t.Setenv("API_PASSWORD", *tt.value)
+			}
+			if tt.file != nil {
+				t.Setenv("API_PASSWORD_FILE", writeFile(t, "password", *tt.file))
+			}
+			got, ok, err := LookupEnv("API_PASSWORD")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LookupEnv() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LookupEnv() error = %v", err)
+			}
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("LookupEnv() = %q, %v; want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestLookupEnvMissingFile(t *testing.T) {
+	isolateEnv(t)
+	t.Setenv("API_PASSWORD_FILE", "/tidak/ada/password")
+	if _, _, err := LookupEnv("API_PASSWORD"); err == nil || !strings.Contains(err.Error(), "API_PASSWORD_FILE: gagal membaca file") {
+		t.Errorf("LookupEnv() error = %v", err)
+	}
+}
+
+func TestLoadEnvFileTrimsNewline(t *testing.T) {
+	isolateEnv(t)
+	t.Setenv("API_USERNAME", "user")
+	t.Setenv("API_PASSWORD_FILE", writeFile(t, "password", "rahasia\n"))
+	cfg, err := Load("", nil)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.API.Password != "rahasia" {
+		t.Errorf("api.password = %q, want %q", cfg.API.Password, "rahasia")
+	}
+}
+
+func TestSecretsRoundTrip(t *testing.T) {
+	key, err := GenerateSecretsKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, _ := GenerateSecretsKey()
+	sealed, err := EncryptSecrets([]byte("api.password: rahasia\n"), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := []byte(string(sealed[:len(sealed)-6]) + "AAAA=\n")
+
+	tests := []struct {
+		name    string
+		data    []byte
+		key     string
+		wantErr string
+	}{
+		{name: "kunci benar", data: sealed, key: key},
+		{name: "kunci salah", data: sealed, key: otherKey, wantErr: "kunci salah"},
+		{name: "kunci bukan 32 byte", data: sealed, key: "c2luZ2thdA==", wantErr: "32 byte"},
+		{name: "isi diubah", data: tampered, key: key, wantErr: "gagal mendekripsi"},
+		{name: "bukan file rahasia", data: []byte("api.password: rahasia\n"), key: key, wantErr: "header tidak dikenali"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := decryptSecrets(tt.data, tt.key)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("decryptSecrets() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if values["api.password"] != "rahasia" {
+				t.Errorf("values = %v", values)
+			}
+		})
+	}
+	if _, err := EncryptSecrets([]byte("- bukan\n- map\n"), key); err == nil {
+		t.Error("EncryptSecrets harus menolak isi yang bukan YAML key: value")
+	}
+}
+
+func TestSecretValuesMasked(t *testing.T) {
+	cfg := Default()
+	cfg.API.Password = "p@ss w0rd"
+	cfg.API.Headers = map[string]string{"X-Api-Key": "kunci-api-12345"}
+	cfg.Database.URL = "postgres://app:dbpass123@db:5432/app"
+
+	r := redact.New(cfg.SecretValues()...)
+	secrets := []string{"p@ss w0rd", "kunci-api-12345", "dbpass123"}
+	err := r.Error(fmt.Errorf("sync: %w", errors.New("request X-Api-Key=kunci-api-12345 user p@ss w0rd ke app:dbpass123")))
+	for _, s := range secrets {
+		if strings.Contains(err.Error(), s) {
+			t.Errorf("error masih memuat %q: %v", s, err)
+		}
+	}
+
+	red := cfg.Redacted()
+	if red.API.Password != redactedValue || red.API.Headers["X-Api-Key"] != redactedValue {
+		t.Errorf("Redacted() = %+v", red.API)
+	}
+	if strings.Contains(red.Database.URL, "dbpass123") || !strings.Contains(red.Database.URL, "db:5432/app") {
+		t.Errorf("database.url = %q", red.Database.URL)
+	}
+	if cfg.API.Headers["X-Api-Key"] != "kunci-api-12345" {
+		t.Error("Redacted() tidak boleh mengubah konfigurasi asli")
+	}
+}
+
+func ptr(s string) *string { return &s }
@@ -0,0 +1,261 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aryadiwwt/synctodb/domain"
+)
+
+// validate memeriksa semua nilai dan mengembalikan seluruh masalah yang
+// ditemukan, bukan hanya yang pertama.
+func (c *Config) validate() []string {
+	var p problems
+
+	p.url("api.url", c.API.URL)
+	p.url("api.login_url", c.API.LoginURL)
+	p.required("api.username", c.API.Username)
+	p.required("api.password", c.API.Password)
+	if c.API.Tahun < 2000 || c.API.Tahun > 2100 {
+		p.addf("api.tahun: %d di luar rentang 2000-2100", c.API.Tahun)
+	}
+	switch c.API.RequestStyle {
+	case RequestStyleGetBody, RequestStylePost, RequestStyleQuery:
+	default:
+		p.addf("api.request_style: %q tidak dikenal (get_body, post, query)", c.API.RequestStyle)
+	}
+	for name := range c.API.Headers {
+		if strings.EqualFold(name, "Authorization") {
+			p.addf("api.headers: Authorization diisi dari hasil login dan tidak bisa diganti")
+		}
+	}
+
+	if p.required("database.url", c.Database.URL) {
+		if _, err := url.Parse(c.Database.URL); err != nil {
+			p.addf("database.url: bukan URL koneksi yang valid")
+		}
+	}
+	if c.Database.MaxOpenConns < 0 {
+		p.addf("database.max_open_conns: tidak boleh negatif")
+	}
+	if c.Database.MaxIdleConns < 0 {
+		p.addf("database.max_idle_conns: tidak boleh negatif")
+	}
+	if c.Database.MaxOpenConns > 0 && c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		p.addf("database.max_idle_conns: %d melebihi max_open_conns %d", c.Database.MaxIdleConns, c.Database.MaxOpenConns)
+	}
+	if c.Database.ConnMaxLifetime < 0 {
+		p.addf("database.conn_max_lifetime: tidak boleh negatif")
+	}
+	if c.Database.ConnMaxIdleTime < 0 {
+		p.addf("database.conn_max_idle_time: tidak boleh negatif")
+	}
+
+	if c.HTTP.Timeout <= 0 {
+		p.addf("http.timeout: harus lebih dari 0")
+	}
+	if c.HTTP.PageConcurrency < 1 {
+		p.addf("http.page_concurrency: minimal 1")
+	}
+	if c.HTTP.RateLimit.Enabled {
+		rl, err := c.HTTP.RateLimit.For(c.API.Username)
+		if err != nil {
+			p.addf("http.rate_limit.credentials: %v", err)
+		} else {
+			if rl.MinRate <= 0 {
+				p.addf("http.rate_limit.min_rate: harus lebih dari 0")
+			}
+			if rl.MaxRate < rl.MinRate {
+				p.addf("http.rate_limit.max_rate: %g lebih kecil dari min_rate %g", rl.MaxRate, rl.MinRate)
+			}
+			if rl.Rate < rl.MinRate || rl.Rate > rl.MaxRate {
+				p.addf("http.rate_limit.rate: %g di luar rentang min_rate-max_rate", rl.Rate)
+			}
+			if rl.Burst < 1 {
+				p.addf("http.rate_limit.burst: minimal 1")
+			}
+			if rl.Increase < 0 || rl.TargetLatency < 0 {
+				p.addf("http.rate_limit: increase dan target_latency tidak boleh negatif")
+			}
+		}
+	}
+	if cb := c.HTTP.CircuitBreaker; cb.Enabled {
+		if cb.FailureThreshold < 1 {
+			p.addf("http.circuit_breaker.failure_threshold: minimal 1")
+		}
+		if cb.OpenDuration <= 0 {
+			p.addf("http.circuit_breaker.open_duration: harus lebih dari 0")
+		}
+		if cb.Budget < cb.OpenDuration {
+			p.addf("http.circuit_breaker.budget: %s lebih kecil dari open_duration %s", cb.Budget, cb.OpenDuration)
+		}
+	}
+	if c.HTTP.Pagination.MaxPages < 0 {
+		p.addf("http.pagination.max_pages: tidak boleh negatif")
+	}
+	for from, to := range c.HTTP.Pagination.HostRewrites {
+		if from == "" || to == "" || strings.ContainsAny(from+to, "/ ") {
+			p.addf("http.pagination.host_rewrites: %q=%q harus berupa host[:port]", from, to)
+		}
+	}
+	transport := c.HTTP.Transport
+	if transport.ProxyURL != "" {
+		// Nilainya tidak dicetak karena bisa memuat password proxy.
+		u, err := url.Parse(transport.ProxyURL)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5") {
+			p.addf("http.transport.proxy_url: bukan URL proxy http(s) atau socks5 yang valid")
+		}
+	} else if transport.NoProxy != "" {
+		p.addf("http.transport.no_proxy: hanya berlaku jika proxy_url diisi")
+	}
+	if (transport.CertFile == "") != (transport.KeyFile == "") {
+		p.addf("http.transport: cert_file dan key_file harus diisi bersamaan")
+	}
+	if _, err := transport.TLSVersion(); err != nil {
+		p.addf("http.transport.tls_min_version: %v", err)
+	}
+	if transport.MaxIdleConns < 0 || transport.MaxIdleConnsPerHost < 0 || transport.MaxConnsPerHost < 0 {
+		p.addf("http.transport: jumlah koneksi tidak boleh negatif")
+	}
+	if transport.IdleConnTimeout < 0 || transport.TLSHandshakeTimeout < 0 {
+		p.addf("http.transport: timeout tidak boleh negatif")
+	}
+	if c.HTTP.Cache.Enabled {
+		p.required("http.cache.dir", c.HTTP.Cache.Dir)
+		if c.HTTP.Cache.TTL < 0 {
+			p.addf("http.cache.ttl: tidak boleh negatif")
+		}
+	}
+
+	for _, kode := range c.Sync.Provinsi {
+		if k, err := domain.ParseKodeWilayah(kode); err != nil {
+			p.addf("sync.provinsi: %v", err)
+		} else if k.Level() != domain.LevelProvinsi {
+			p.addf("sync.provinsi: %q bukan kode provinsi", kode)
+		}
+	}
+	if c.Sync.StartKabupaten != "" {
+		if k, err := domain.ParseKodeWilayah(c.Sync.StartKabupaten); err != nil {
+			p.addf("sync.start_kabupaten: %v", err)
+		} else if k.Level() > domain.LevelKabupaten {
+			p.addf("sync.start_kabupaten: %q bukan kode kabupaten", c.Sync.StartKabupaten)
+		}
+	}
+	if c.Sync.Delay < 0 {
+		p.addf("sync.delay: tidak boleh negatif")
+	}
+	if c.Sync.RunTimeout <= 0 {
+		p.addf("sync.run_timeout: harus lebih dari 0")
+	}
+	if c.HTTP.PageTimeout < 0 {
+		p.addf("http.page_timeout: tidak boleh negatif")
+	}
+	if c.Sync.WilayahTimeout < 0 {
+		p.addf("sync.wilayah_timeout: tidak boleh negatif")
+	}
+	// Batas waktu bertingkat: request <= halaman <= wilayah <= run. Batas 0
+	// (tanpa batas sendiri) dilewati.
+	p.nested("http.timeout", c.HTTP.Timeout, "http.page_timeout", c.HTTP.PageTimeout)
+	p.nested("http.page_timeout", c.HTTP.PageTimeout, "sync.wilayah_timeout", c.Sync.WilayahTimeout)
+	p.nested("sync.wilayah_timeout", c.Sync.WilayahTimeout, "sync.run_timeout", c.Sync.RunTimeout)
+	if len(c.Sync.Resources) == 0 {
+		p.addf("sync.resources: minimal satu resource")
+	}
+
+	if c.Transform.Profile == "" && len(c.Transform.Steps) == 0 {
+		p.addf("transform.profile: wajib diisi jika transform.steps kosong")
+	}
+
+	if c.Verify.MaxCountDiff < 0 {
+		p.addf("verify.max_count_diff: tidak boleh negatif")
+	}
+	if d, err := domain.ParseDecimal(c.Verify.MaxAmountDiff); err != nil {
+		p.addf("verify.max_amount_diff: %q bukan angka desimal", c.Verify.MaxAmountDiff)
+	} else if d.Sign() < 0 {
+		p.addf("verify.max_amount_diff: tidak boleh negatif")
+	}
+
+	if len(c.Storer.Sinks) == 0 {
+		p.addf("storer.sinks: minimal satu sink")
+	}
+	hasPostgres := false
+	for _, sink := range c.Storer.Sinks {
+		switch sink {
+		case SinkPostgres:
+			hasPostgres = true
+		case SinkParquet:
+			p.required("storer.parquet_dir", c.Storer.ParquetDir)
+		default:
+			p.addf("storer.sinks: %q tidak dikenal (gunakan postgres atau parquet)", sink)
+		}
+	}
+	if c.Storer.Aggregates && !hasPostgres {
+		p.addf("storer.aggregates: memerlukan sink postgres")
+	}
+	if c.Storer.RowHash && !hasPostgres {
+		p.addf("storer.row_hash: memerlukan sink postgres")
+	}
+	if c.Sync.SkipUnchanged && !hasPostgres {
+		p.addf("sync.skip_unchanged: memerlukan sink postgres untuk menyimpan content hash")
+	}
+
+	switch c.Wilayah.Source {
+	case WilayahSourceTable:
+		p.identifier("wilayah.table", c.Wilayah.Table)
+		p.identifier("wilayah.provinsi_column", c.Wilayah.ProvinsiColumn)
+		p.identifier("wilayah.kabupaten_column", c.Wilayah.KabupatenColumn)
+	case WilayahSourceEmbedded:
+	case WilayahSourceCSV:
+		p.required("wilayah.csv_file", c.Wilayah.CSVFile)
+	default:
+		p.addf("wilayah.source: %q tidak dikenal (gunakan table, embedded atau csv)", c.Wilayah.Source)
+	}
+
+	return p
+}
+
+// identifierPattern sama dengan aturan storer.ValidIdentifier; diduplikasi
+// agar package config tidak bergantung pada storer.
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+type problems []string
+
+func (p *problems) addf(format string, args ...interface{}) {
+	*p = append(*p, fmt.Sprintf(format, args...))
+}
+
+// nested memastikan batas waktu inner tidak melebihi outer yang
+// membungkusnya.
+func (p *problems) nested(innerKey string, inner time.Duration, outerKey string, outer time.Duration) {
+	if inner > 0 && outer > 0 && inner > outer {
+		p.addf("%s: %s melebihi %s %s", innerKey, inner, outerKey, outer)
+	}
+}
+
+// required mencatat masalah jika value kosong dan mengembalikan true jika terisi.
+func (p *problems) required(key, value string) bool {
+	if value == "" {
+		p.addf("%s: wajib diisi", key)
+		return false
+	}
+	return true
+}
+
+func (p *problems) url(key, value string) {
+	if !p.required(key, value) {
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		p.addf("%s: %q bukan URL http(s) yang valid", key, value)
+	}
+}
+
+func (p *problems) identifier(key, value string) {
+	if !identifierPattern.MatchString(value) {
+		p.addf("%s: %q bukan nama tabel/kolom yang valid", key, value)
+	}
+}
@@ -0,0 +1,310 @@
+package domain
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal adalah bilangan desimal fixed-point untuk nilai uang (rupiah).
+// Nilainya disimpan sebagai bilangan bulat tanpa skala (unscaled) dan jumlah
+// digit di belakang koma (scale), sehingga tidak ada pembulatan biner seperti
+// pada float64. Zero value dari Decimal bernilai 0 dan siap dipakai.
+type Decimal struct {
+	unscaled *big.Int // nil berarti nol; tidak pernah dimutasi setelah dibuat
+	scale    int32    // jumlah digit di belakang koma, selalu >= 0
+}
+
+// NewDecimal membuat Decimal dari nilai unscaled dan scale, misal
+// NewDecimal(12345, 2) bernilai 123.45.
+func NewDecimal(unscaled int64, scale int32) Decimal {
+	if scale < 0 {
+		return Decimal{unscaled: new(big.Int).Mul(big.NewInt(unscaled), pow10(-scale))}
+	}
+	return Decimal{unscaled: big.NewInt(unscaled), scale: scale}
+}
+
+// DecimalFromInt membuat Decimal dari bilangan bulat.
+func DecimalFromInt(v int64) Decimal {
+	return NewDecimal(v, 0)
+}
+
+// Batas input ParseDecimal. Nilai datang dari JSON API yang tidak bisa
+// dipercaya sepenuhnya, sehingga eksponen besar ("1e999999999") tidak boleh
+// sampai ke big.Int.Exp dan scale harus tetap muat di int32.
+const (
+	maxDecimalExponent = 64
+	maxDecimalScale    = 128
+)
+
+// ParseDecimal mengubah string seperti "1234567.89", "-0.5" atau "1.5e6"
+// menjadi Decimal tanpa kehilangan presisi. Eksponen di luar ±64 dan hasil
+// dengan lebih dari 128 digit di belakang koma ditolak.
+func ParseDecimal(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Decimal{}, fmt.Errorf("decimal: empty string")
+	}
+
+	mantissa, exp := s, int64(0)
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.ParseInt(s[i+1:], 10, 32)
+		if err != nil {
+			return Decimal{}, fmt.Errorf("decimal: invalid exponent in %q", s)
+		}
+		if e > maxDecimalExponent || e < -maxDecimalExponent {
+			return Decimal{}, fmt.Errorf("decimal: exponent out of range in %q", s)
+		}
+		mantissa, exp = s[:i], e
+	}
+
+	intPart, fracPart := mantissa, ""
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		intPart, fracPart = mantissa[:i], mantissa[i+1:]
+	}
+
+	sign := ""
+	if intPart != "" && (intPart[0] == '-' || intPart[0] == '+') {
+		sign, intPart = intPart[:1], intPart[1:]
+	}
+	if intPart == "" && fracPart == "" {
+		return Decimal{}, fmt.Errorf("decimal: invalid syntax %q", s)
+	}
+	if !isDigits(intPart) || !isDigits(fracPart) {
+		return Decimal{}, fmt.Errorf("decimal: invalid syntax %q", s)
+	}
+
+	scale := int64(len(fracPart)) - exp
+	if scale > maxDecimalScale {
+		return Decimal{}, fmt.Errorf("decimal: too many fractional digits in %q", s)
+	}
+
+	digits := intPart + fracPart
+	if digits == "" {
+		digits = "0"
+	}
+	unscaled, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("decimal: invalid syntax %q", s)
+	}
+	if sign == "-" {
+		unscaled.Neg(unscaled)
+	}
+
+	if scale < 0 {
+		unscaled.Mul(unscaled, pow10(int32(-scale)))
+		scale = 0
+	}
+	return Decimal{unscaled: unscaled, scale: int32(scale)}, nil
+}
+
+// MustParseDecimal sama seperti ParseDecimal tetapi panic jika gagal.
+// Hanya untuk konstanta di dalam kode.
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func (d Decimal) value() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+	return d.unscaled
+}
+
+// rescaled mengembalikan nilai unscaled dengan scale yang lebih besar.
+func (d Decimal) rescaled(scale int32) *big.Int {
+	if scale <= d.scale {
+		return new(big.Int).Set(d.value())
+	}
+	return new(big.Int).Mul(d.value(), pow10(scale-d.scale))
+}
+
+// Scale mengembalikan jumlah digit di belakang koma.
+func (d Decimal) Scale() int32 { return d.scale }
+
+// Add mengembalikan d + o.
+func (d Decimal) Add(o Decimal) Decimal {
+	scale := max(d.scale, o.scale)
+	return Decimal{unscaled: new(big.Int).Add(d.rescaled(scale), o.rescaled(scale)), scale: scale}
+}
+
+// Sub mengembalikan d - o.
+func (d Decimal) Sub(o Decimal) Decimal {
+	scale := max(d.scale, o.scale)
+	return Decimal{unscaled: new(big.Int).Sub(d.rescaled(scale), o.rescaled(scale)), scale: scale}
+}
+
+// Mul mengembalikan d * o.
+func (d Decimal) Mul(o Decimal) Decimal {
+	return Decimal{unscaled: new(big.Int).Mul(d.value(), o.value()), scale: d.scale + o.scale}
+}
+
+// Neg mengembalikan -d.
+func (d Decimal) Neg() Decimal {
+	return Decimal{unscaled: new(big.Int).Neg(d.value()), scale: d.scale}
+}
+
+// Abs mengembalikan |d|.
+func (d Decimal) Abs() Decimal {
+	return Decimal{unscaled: new(big.Int).Abs(d.value()), scale: d.scale}
+}
+
+// Cmp membandingkan d dengan o: -1 jika d < o, 0 jika sama, +1 jika d > o.
+func (d Decimal) Cmp(o Decimal) int {
+	scale := max(d.scale, o.scale)
+	return d.rescaled(scale).Cmp(o.rescaled(scale))
+}
+
+// Equal melaporkan apakah d dan o bernilai sama, terlepas dari scale-nya.
+func (d Decimal) Equal(o Decimal) bool { return d.Cmp(o) == 0 }
+
+// Sign mengembalikan -1, 0 atau +1 sesuai tanda d.
+func (d Decimal) Sign() int { return d.value().Sign() }
+
+// IsZero melaporkan apakah d bernilai nol.
+func (d Decimal) IsZero() bool { return d.Sign() == 0 }
+
+// Round membulatkan d ke sejumlah digit di belakang koma (half away from zero).
+func (d Decimal) Round(places int32) Decimal {
+	if places < 0 {
+		places = 0
+	}
+	if places >= d.scale {
+		return Decimal{unscaled: d.rescaled(places), scale: places}
+	}
+	divisor := pow10(d.scale - places)
+	q, r := new(big.Int).QuoRem(d.value(), divisor, new(big.Int))
+	// Bulatkan menjauhi nol jika sisa >= setengah pembagi.
+	if new(big.Int).Mul(new(big.Int).Abs(r), big.NewInt(2)).Cmp(divisor) >= 0 {
+		if d.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return Decimal{unscaled: q, scale: places}
+}
+
+// UnscaledInt64 membulatkan d ke scale digit di belakang koma dan
+// mengembalikan nilai unscaled-nya, misal 1234.5 dengan scale 2 menjadi
+// 123450. ok bernilai false jika hasilnya tidak muat di int64.
+func (d Decimal) UnscaledInt64(scale int32) (v int64, ok bool) {
+	u := d.Round(scale).value()
+	if !u.IsInt64() {
+		return 0, false
+	}
+	return u.Int64(), true
+}
+
+// Float64 mengembalikan perkiraan float64 dari d. Hanya untuk tampilan atau
+// perhitungan persentase, bukan untuk menyimpan nilai uang.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// String mengembalikan representasi desimal tanpa eksponen, misal "-1234.50".
+func (d Decimal) String() string {
+	s := d.value().String()
+	if d.scale == 0 {
+		return s
+	}
+
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	if pad := int(d.scale) - len(s) + 1; pad > 0 {
+		s = strings.Repeat("0", pad) + s
+	}
+	s = s[:len(s)-int(d.scale)] + "." + s[len(s)-int(d.scale):]
+	if neg {
+		s = "-" + s
+	}
+	return s
+}
+
+// MarshalJSON menulis Decimal sebagai string JSON agar presisi tetap terjaga
+// di sisi konsumen (sama seperti format yang dikirim API).
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON menerima nilai dalam bentuk string ("1234.50") maupun
+// number (1234.50). null dibiarkan sebagai nol.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*d = Decimal{}
+		return nil
+	}
+
+	raw := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return fmt.Errorf("decimal: %w", err)
+		}
+	}
+
+	parsed, err := ParseDecimal(raw)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Value mengimplementasikan driver.Valuer. Nilai dikirim sebagai string agar
+// kolom NUMERIC menerima angka persis seperti aslinya.
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// Scan mengimplementasikan sql.Scanner untuk kolom NUMERIC, integer maupun
+// teks. NULL dibaca sebagai nol.
+func (d *Decimal) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*d = Decimal{}
+		return nil
+	case []byte:
+		return d.scanString(string(v))
+	case string:
+		return d.scanString(v)
+	case int64:
+		*d = DecimalFromInt(v)
+		return nil
+	case float64:
+		return d.scanString(strconv.FormatFloat(v, 'f', -1, 64))
+	default:
+		return fmt.Errorf("decimal: cannot scan type %T", src)
+	}
+}
+
+func (d *Decimal) scanString(s string) error {
+	parsed, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
@@ -0,0 +1,165 @@
+package domain
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "1234567.89", want: "1234567.89"},
+		{in: "-0.5", want: "-0.5"},
+		{in: "+12", want: "12"},
+		{in: " 7 ", want: "7"},
+		{in: ".25", want: "0.25"},
+		{in: "5.", want: "5"},
+		{in: "1.5e6", want: "1500000"},
+		{in: "1.5E-2", want: "0.015"},
+		{in: "0.10", want: "0.10"},
+		{in: "1e64", want: "1" + strings.Repeat("0", 64)},
+		{in: "1e-64", want: "0." + strings.Repeat("0", 63) + "1"},
+		{in: "", wantErr: true},
+		{in: "-", wantErr: true},
+		{in: ".", wantErr: true},
+		{in: "1,5", wantErr: true},
+		{in: "abc", wantErr: true},
+		{in: "1e", wantErr: true},
+		{in: "1e65", wantErr: true},
+		{in: "1e-65", wantErr: true},
+		{in: "1e999999999", wantErr: true},
+		{in: "1e-3000000000", wantErr: true},
+		{in: "0." + strings.Repeat("0", 129), wantErr: true},
+		{in: "1." + strings.Repeat("0", 100) + "e-64", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseDecimal(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseDecimal(%q) = %s, want error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDecimal(%q): %v", tt.in, err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("ParseDecimal(%q) = %s, want %s", tt.in, got, tt.want)
+			}
+			if got.Scale() < 0 {
+				t.Errorf("ParseDecimal(%q) scale = %d, want >= 0", tt.in, got.Scale())
+			}
+		})
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	a, b := MustParseDecimal("10.05"), MustParseDecimal("-0.1")
+	tests := []struct {
+		name string
+		got  Decimal
+		want string
+	}{
+		{"add", a.Add(b), "9.95"},
+		{"sub", a.Sub(b), "10.15"},
+		{"mul", a.Mul(b), "-1.005"},
+		{"neg", b.Neg(), "0.1"},
+		{"abs", b.Abs(), "0.1"},
+		{"zero add", Decimal{}.Add(a), "10.05"},
+		{"round half up", MustParseDecimal("1.005").Round(2), "1.01"},
+		{"round half away from zero", MustParseDecimal("-1.005").Round(2), "-1.01"},
+		{"round down", MustParseDecimal("1.004").Round(2), "1.00"},
+		{"round widen", MustParseDecimal("1.5").Round(2), "1.50"},
+		{"new negative scale", NewDecimal(12, -2), "1200"},
+	}
+	for _, tt := range tests {
+		if tt.got.String() != tt.want {
+			t.Errorf("%s = %s, want %s", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestDecimalCmp(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0", "1", 0},
+		{"1.01", "1.1", -1},
+		{"-2", "-10", 1},
+		{"0", "-0.00", 0},
+	}
+	for _, tt := range tests {
+		if got := MustParseDecimal(tt.a).Cmp(MustParseDecimal(tt.b)); got != tt.want {
+			t.Errorf("Cmp(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+	if !(Decimal{}).IsZero() {
+		t.Error("zero value Decimal is not zero")
+	}
+}
+
+func TestDecimalUnscaledInt64(t *testing.T) {
+	v, ok := MustParseDecimal("1234.5").UnscaledInt64(2)
+	if !ok || v != 123450 {
+		t.Errorf("UnscaledInt64 = %d, %v; want 123450, true", v, ok)
+	}
+	if _, ok := MustParseDecimal("1e40").UnscaledInt64(0); ok {
+		t.Error("UnscaledInt64 overflow reported ok")
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{`"1234.50"`, "1234.50"},
+		{`1234.5`, "1234.5"},
+		{`null`, "0"},
+	}
+	for _, tt := range tests {
+		var d Decimal
+		if err := json.Unmarshal([]byte(tt.in), &d); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", tt.in, err)
+		}
+		if d.String() != tt.want {
+			t.Errorf("Unmarshal(%s) = %s, want %s", tt.in, d, tt.want)
+		}
+	}
+	var d Decimal
+	if err := json.Unmarshal([]byte(`"1e999999999"`), &d); err == nil {
+		t.Error("Unmarshal huge exponent: want error")
+	}
+
+	out, err := json.Marshal(MustParseDecimal("-0.05"))
+	if err != nil || string(out) != `"-0.05"` {
+		t.Errorf("Marshal = %s, %v; want \"-0.05\"", out, err)
+	}
+}
+
+func TestDecimalScan(t *testing.T) {
+	tests := []struct {
+		src  interface{}
+		want string
+	}{
+		{nil, "0"},
+		{[]byte("12.30"), "12.30"},
+		{"4", "4"},
+		{int64(-7), "-7"},
+		{1.25, "1.25"},
+	}
+	for _, tt := range tests {
+		var d Decimal
+		if err := d.Scan(tt.src); err != nil {
+			t.Fatalf("Scan(%v): %v", tt.src, err)
+		}
+		if d.String() != tt.want {
+			t.Errorf("Scan(%v) = %s, want %s", tt.src, d, tt.want)
+		}
+	}
+}
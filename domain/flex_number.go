@@ -0,0 +1,130 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Alasan koersi yang dicatat oleh FlexNumber.
+const (
+	CoercionNull    = "null"    // API mengirim null
+	CoercionEmpty   = "empty"   // API mengirim string kosong atau "-"
+	CoercionLocale  = "locale"  // format lokal, misal "1.234.567,00"
+	CoercionInvalid = "invalid" // tidak bisa dibaca sama sekali, dianggap 0
+	// CoercionAmbiguous: string dengan satu titik diikuti tepat tiga digit,
+	// misal "1.234". Dibaca sebagai desimal (1.234), tetapi dalam format
+	// Indonesia bisa berarti seribu dua ratus tiga puluh empat.
+	CoercionAmbiguous = "ambiguous"
+)
+
+// FlexNumber adalah angka dari API yang formatnya tidak konsisten. Ia menerima
+// string ("1234.5"), number (1234.5), null, string kosong, dan format angka
+// Indonesia ("1.234.567,00"). Nilai yang tidak bisa dibaca sama sekali tidak
+// menggagalkan decoding, melainkan dianggap 0 dan ditandai lewat Coercion.
+type FlexNumber struct {
+	Number   Decimal
+	Raw      string // nilai mentah dari API, hanya diisi jika terjadi koersi
+	Coercion string // alasan koersi; kosong jika nilai dibaca apa adanya
+}
+
+// Coerced melaporkan apakah nilai perlu dikoersi saat dibaca.
+func (n FlexNumber) Coerced() bool { return n.Coercion != "" }
+
+// Float64 mengembalikan nilai sebagai float64.
+func (n FlexNumber) Float64() float64 { return n.Number.Float64() }
+
+// String mengembalikan nilai angka yang sudah dinormalisasi.
+func (n FlexNumber) String() string { return n.Number.String() }
+
+// UnmarshalJSON mengimplementasikan json.Unmarshaler. Fungsi ini hanya
+// mengembalikan error jika input bukan JSON scalar (misal object atau array).
+func (n *FlexNumber) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	*n = FlexNumber{}
+
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		n.Coercion = CoercionNull
+		return nil
+	case len(data) > 0 && data[0] == '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return fmt.Errorf("flex number: %w", err)
+		}
+		n.parse(s, true)
+		return nil
+	case len(data) > 0 && (data[0] == '{' || data[0] == '['):
+		return fmt.Errorf("flex number: unexpected JSON value %s", data)
+	default:
+		// Raw number (atau true/false yang akan ditandai invalid).
+		n.parse(string(data), false)
+		return nil
+	}
+}
+
+// parse membaca s; quoted menandai nilai yang dikirim sebagai string JSON.
+// Number JSON selalu memakai titik desimal sehingga tidak pernah ambigu.
+func (n *FlexNumber) parse(s string, quoted bool) {
+	cleaned := strings.TrimSpace(s)
+	cleaned = strings.TrimPrefix(strings.TrimPrefix(cleaned, "Rp"), "rp")
+	cleaned = strings.NewReplacer(" ", "", "\u00a0", "").Replace(cleaned)
+
+	if cleaned == "" || cleaned == "-" {
+		n.Raw, n.Coercion = s, CoercionEmpty
+		return
+	}
+
+	if d, err := ParseDecimal(cleaned); err == nil {
+		n.Number = d
+		switch {
+		case quoted && ambiguousThousands(cleaned):
+			n.Raw, n.Coercion = s, CoercionAmbiguous
+		case cleaned != strings.TrimSpace(s):
+			n.Raw, n.Coercion = s, CoercionLocale
+		}
+		return
+	}
+
+	if d, err := ParseDecimal(normalizeLocaleNumber(cleaned)); err == nil {
+		n.Number, n.Raw, n.Coercion = d, s, CoercionLocale
+		return
+	}
+
+	n.Raw, n.Coercion = s, CoercionInvalid
+}
+
+// normalizeLocaleNumber mengubah angka berformat ribuan menjadi format
+// desimal biasa. Jika ada koma, format dianggap Indonesia ("1.234,5") kecuali
+// titik muncul setelah koma terakhir ("1,234.5"). Tanpa koma, beberapa titik
+// dianggap pemisah ribuan ("1.234.567").
+func normalizeLocaleNumber(s string) string {
+	lastComma := strings.LastIndexByte(s, ',')
+	lastDot := strings.LastIndexByte(s, '.')
+
+	switch {
+	case lastComma >= 0 && lastDot > lastComma:
+		return strings.ReplaceAll(s, ",", "")
+	case lastComma >= 0:
+		return strings.Replace(strings.ReplaceAll(s, ".", ""), ",", ".", 1)
+	case strings.Count(s, ".") > 1:
+		return strings.ReplaceAll(s, ".", "")
+	default:
+		return s
+	}
+}
+
+// ambiguousThousands melaporkan apakah s berbentuk "1.234": satu sampai tiga
+// digit tanpa nol di depan, satu titik, lalu tepat tiga digit. Bentuk ini
+// sah sebagai desimal maupun sebagai angka ribuan format Indonesia.
+func ambiguousThousands(s string) bool {
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		s = s[1:]
+	}
+	i := strings.IndexByte(s, '.')
+	if i < 1 || i > 3 || len(s)-i-1 != 3 || s[0] == '0' {
+		return false
+	}
+	return isDigits(s[:i]) && isDigits(s[i+1:])
+}
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestFlexNumberUnmarshal(t *testing.T) {
+	tests := []struct {
+		in       string
+		want     string
+		coercion string
+	}{
+		{in: `1234.5`, want: "1234.5"},
+		{in: `"1234.5"`, want: "1234.5"},
+		{in: `"-0.5"`, want: "-0.5"},
+		{in: `0`, want: "0"},
+		{in: `null`, want: "0", coercion: CoercionNull},
+		{in: `""`, want: "0", coercion: CoercionEmpty},
+		{in: `"-"`, want: "0", coercion: CoercionEmpty},
+		{in: `"1.234.567,00"`, want: "1234567.00", coercion: CoercionLocale},
+		{in: `"1.234,5"`, want: "1234.5", coercion: CoercionLocale},
+		{in: `"1,234.5"`, want: "1234.5", coercion: CoercionLocale},
+		{in: `"1.234.567"`, want: "1234567", coercion: CoercionLocale},
+		{in: `"Rp 1.500.000"`, want: "1500000", coercion: CoercionLocale},
+		{in: `" 12 "`, want: "12"},
+		// Satu titik diikuti tepat tiga digit: desimal atau ribuan.
+		{in: `"1.234"`, want: "1.234", coercion: CoercionAmbiguous},
+		{in: `"-12.500"`, want: "-12.500", coercion: CoercionAmbiguous},
+		{in: `"123.000"`, want: "123.000", coercion: CoercionAmbiguous},
+		{in: `1.234`, want: "1.234"}, // number JSON selalu desimal
+		{in: `"0.125"`, want: "0.125"},
+		{in: `"1234.567"`, want: "1234.567"},
+		{in: `"1.23"`, want: "1.23"},
+		{in: `"1.2345"`, want: "1.2345"},
+		{in: `"abc"`, want: "0", coercion: CoercionInvalid},
+		{in: `true`, want: "0", coercion: CoercionInvalid},
+		{in: `"1e999999999"`, want: "0", coercion: CoercionInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			var n FlexNumber
+			if err := json.Unmarshal([]byte(tt.in), &n); err != nil {
+				t.Fatalf("Unmarshal(%s): %v", tt.in, err)
+			}
+			if n.String() != tt.want {
+				t.Errorf("Unmarshal(%s) = %s, want %s", tt.in, n, tt.want)
+			}
+			if n.Coercion != tt.coercion {
+				t.Errorf("Unmarshal(%s) coercion = %q, want %q", tt.in, n.Coercion, tt.coercion)
+			}
+		})
+	}
+}
+
+func TestFlexNumberRejectsComposite(t *testing.T) {
+	for _, in := range []string{`{}`, `[1]`} {
+		var n FlexNumber
+		if err := json.Unmarshal([]byte(in), &n); err == nil {
+			t.Errorf("Unmarshal(%s): want error", in)
+		}
+	}
+}
+
+func TestOutputDetailNotesAmbiguousMoney(t *testing.T) {
+	var d OutputDetail
+	if err := json.Unmarshal([]byte(`{"id_keg":"K1","no_id":"1","pagu":"1.234","nilai":"2000"}`), &d); err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Coercions) != 1 {
+		t.Fatalf("Coercions = %v, want one entry for pagu", d.Coercions)
+	}
+	if want := `pagu: ambiguous ("1.234")`; d.Coercions[0] != want {
+		t.Errorf("Coercions[0] = %q, want %q", d.Coercions[0], want)
+	}
+}
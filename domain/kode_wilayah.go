@@ -0,0 +1,240 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Tingkat kode wilayah Kemendagri.
+const (
+	LevelProvinsi  = 1
+	LevelKabupaten = 2
+	LevelKecamatan = 3
+	LevelDesa      = 4
+)
+
+// segmentWidths adalah jumlah digit setiap segmen, per tingkat.
+var segmentWidths = [...]int{2, 2, 2, 4}
+
+// KodeWilayah adalah kode wilayah Kemendagri bertingkat, dari provinsi
+// ("51") sampai desa ("51.03.03.2001"). Nilai nol berarti kode kosong.
+type KodeWilayah struct {
+	segments []string
+}
+
+// ParseKodeWilayah mem-parse kode lengkap dalam bentuk yang dijumpai di API
+// dan database:
+//
+//   - bertitik: "51.03", "51.03.03.2001", termasuk titik di akhir ("51.03.")
+//   - tanpa titik: "51", "5103", "510303", "5103032001"
+//   - tanpa nol di depan: "5" atau "51.3"
+//
+// Kode relatif seperti kd_kab "03" tanpa provinsinya dibentuk dengan Child.
+func ParseKodeWilayah(s string) (KodeWilayah, error) {
+	s = strings.TrimSuffix(strings.TrimSpace(s), ".")
+	if s == "" {
+		return KodeWilayah{}, fmt.Errorf("kode wilayah kosong")
+	}
+
+	var parts []string
+	if strings.Contains(s, ".") {
+		parts = strings.Split(s, ".")
+	} else {
+		var err error
+		if parts, err = splitCompact(s); err != nil {
+			return KodeWilayah{}, err
+		}
+	}
+	return KodeWilayah{}.append(s, parts)
+}
+
+// MustParseKodeWilayah seperti ParseKodeWilayah tetapi panic jika gagal.
+func MustParseKodeWilayah(s string) KodeWilayah {
+	k, err := ParseKodeWilayah(s)
+	if err != nil {
+		panic(err)
+	}
+	return k
+}
+
+// splitCompact memecah kode tanpa titik berdasarkan panjangnya.
+func splitCompact(s string) ([]string, error) {
+	switch len(s) {
+	case 1, 2:
+		return []string{s}, nil
+	case 4:
+		return []string{s[:2], s[2:4]}, nil
+	case 6:
+		return []string{s[:2], s[2:4], s[4:6]}, nil
+	case 10:
+		return []string{s[:2], s[2:4], s[4:6], s[6:]}, nil
+	}
+	return nil, fmt.Errorf("kode wilayah %q: panjang %d tidak sesuai tingkat mana pun", s, len(s))
+}
+
+// Child menambahkan kode relatif di bawah k, misal kode provinsi "51" dengan
+// kd_kab "03", atau kode kabupaten "51.03" dengan kd_desa "03.2001." yang
+// sudah memuat kode kecamatan.
+func (k KodeWilayah) Child(relative string) (KodeWilayah, error) {
+	relative = strings.TrimSuffix(strings.TrimSpace(relative), ".")
+	if relative == "" {
+		return KodeWilayah{}, fmt.Errorf("kode wilayah relatif kosong")
+	}
+	return k.append(relative, strings.Split(relative, "."))
+}
+
+// Qualify membentuk kode tingkat level dari value di bawah k. Value yang
+// sudah berupa kode lengkap (bertitik atau tanpa titik) dengan induk k
+// dipakai apa adanya, sehingga "51.03" di bawah "51" tidak menjadi
+// "51.51.03"; selain itu value diperlakukan sebagai kode relatif (Child).
+func (k KodeWilayah) Qualify(value string, level int) (KodeWilayah, error) {
+	if full, err := ParseKodeWilayah(value); err == nil &&
+		full.Level() == level && full.Parent(k.Level()).Equal(k) {
+		return full, nil
+	}
+	return k.Child(value)
+}
+
+func (k KodeWilayah) append(input string, parts []string) (KodeWilayah, error) {
+	if len(k.segments)+len(parts) > len(segmentWidths) {
+		return KodeWilayah{}, fmt.Errorf("kode wilayah %q: lebih dari %d tingkat", input, len(segmentWidths))
+	}
+
+	segments := append([]string(nil), k.segments...)
+	for _, part := range parts {
+		width := segmentWidths[len(segments)]
+		if part == "" || len(part) > width {
+			return KodeWilayah{}, fmt.Errorf("kode wilayah %q: segmen %q harus 1-%d digit", input, part, width)
+		}
+		if strings.Trim(part, "0123456789") != "" {
+			return KodeWilayah{}, fmt.Errorf("kode wilayah %q: segmen %q bukan angka", input, part)
+		}
+		segments = append(segments, strings.Repeat("0", width-len(part))+part)
+	}
+	return KodeWilayah{segments: segments}, nil
+}
+
+// Level mengembalikan tingkat kode (LevelProvinsi..LevelDesa), 0 jika kosong.
+func (k KodeWilayah) Level() int {
+	return len(k.segments)
+}
+
+func (k KodeWilayah) IsZero() bool {
+	return len(k.segments) == 0
+}
+
+// Parent mengembalikan kode pada tingkat level, misal Parent(LevelKabupaten)
+// dari kode desa. Level di atas tingkat k mengembalikan k sendiri.
+func (k KodeWilayah) Parent(level int) KodeWilayah {
+	if level < 0 {
+		level = 0
+	}
+	if level >= len(k.segments) {
+		return k
+	}
+	return KodeWilayah{segments: k.segments[:level]}
+}
+
+// Segment mengembalikan segmen terakhir yang sudah di-pad, misal "03" untuk
+// kabupaten "51.03". Ini bentuk kd_kab yang dipakai request API.
+func (k KodeWilayah) Segment() string {
+	if len(k.segments) == 0 {
+		return ""
+	}
+	return k.segments[len(k.segments)-1]
+}
+
+// Dotted mengembalikan kode lengkap bertitik, misal "51.03.03.2001".
+func (k KodeWilayah) Dotted() string {
+	return strings.Join(k.segments, ".")
+}
+
+// Compact mengembalikan kode lengkap tanpa titik, misal "5103032001".
+func (k KodeWilayah) Compact() string {
+	return strings.Join(k.segments, "")
+}
+
+// Format mengembalikan kode lengkap dengan pemisah sep.
+func (k KodeWilayah) Format(sep string) string {
+	return strings.Join(k.segments, sep)
+}
+
+func (k KodeWilayah) String() string {
+	return k.Dotted()
+}
+
+func (k KodeWilayah) Equal(other KodeWilayah) bool {
+	return k.Dotted() == other.Dotted()
+}
+
+// MarshalJSON menulis kode sebagai string bertitik, atau null jika kosong.
+func (k KodeWilayah) MarshalJSON() ([]byte, error) {
+	if k.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(k.Dotted())
+}
+
+// UnmarshalJSON menerima string atau angka dalam bentuk apa pun yang
+// diterima ParseKodeWilayah, dan null.
+func (k *KodeWilayah) UnmarshalJSON(data []byte) error {
+	raw := strings.TrimSpace(string(data))
+	if raw == "null" {
+		*k = KodeWilayah{}
+		return nil
+	}
+	if strings.HasPrefix(raw, `"`) {
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+		if raw == "" {
+			*k = KodeWilayah{}
+			return nil
+		}
+	}
+	parsed, err := ParseKodeWilayah(raw)
+	if err != nil {
+		return err
+	}
+	*k = parsed
+	return nil
+}
+
+// Value menyimpan kode sebagai teks bertitik; kode kosong menjadi NULL.
+func (k KodeWilayah) Value() (driver.Value, error) {
+	if k.IsZero() {
+		return nil, nil
+	}
+	return k.Dotted(), nil
+}
+
+// Scan membaca kode dari kolom teks atau angka.
+func (k *KodeWilayah) Scan(src interface{}) error {
+	var raw string
+	switch v := src.(type) {
+	case nil:
+		*k = KodeWilayah{}
+		return nil
+	case string:
+		raw = v
+	case []byte:
+		raw = string(v)
+	case int64:
+		raw = strconv.FormatInt(v, 10)
+	default:
+		return fmt.Errorf("KodeWilayah: tidak bisa scan dari %T", src)
+	}
+	if strings.TrimSpace(raw) == "" {
+		*k = KodeWilayah{}
+		return nil
+	}
+	parsed, err := ParseKodeWilayah(raw)
+	if err != nil {
+		return err
+	}
+	*k = parsed
+	return nil
+}
@@ -0,0 +1,153 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseKodeWilayah(t *testing.T) {
+	tests := []struct {
+		in      string
+		dotted  string
+		level   int
+		wantErr bool
+	}{
+		{in: "51", dotted: "51", level: LevelProvinsi},
+		{in: "5", dotted: "05", level: LevelProvinsi},
+		{in: "51.03", dotted: "51.03", level: LevelKabupaten},
+		{in: "51.3", dotted: "51.03", level: LevelKabupaten},
+		{in: "51.03.", dotted: "51.03", level: LevelKabupaten},
+		{in: " 5103 ", dotted: "51.03", level: LevelKabupaten},
+		{in: "510303", dotted: "51.03.03", level: LevelKecamatan},
+		{in: "51.03.03.2001", dotted: "51.03.03.2001", level: LevelDesa},
+		{in: "5103032001", dotted: "51.03.03.2001", level: LevelDesa},
+		{in: "", wantErr: true},
+		{in: ".", wantErr: true},
+		{in: "12345", wantErr: true},
+		{in: "51.x", wantErr: true},
+		{in: "51..03", wantErr: true},
+		{in: "51.003", wantErr: true},
+		{in: "51.03.03.2001.1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			k, err := ParseKodeWilayah(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseKodeWilayah(%q) = %s, want error", tt.in, k)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseKodeWilayah(%q): %v", tt.in, err)
+			}
+			if k.Dotted() != tt.dotted || k.Level() != tt.level {
+				t.Errorf("ParseKodeWilayah(%q) = %s level %d, want %s level %d", tt.in, k.Dotted(), k.Level(), tt.dotted, tt.level)
+			}
+		})
+	}
+}
+
+func TestKodeWilayahChild(t *testing.T) {
+	tests := []struct {
+		parent, relative string
+		want             string
+		wantErr          bool
+	}{
+		{parent: "51", relative: "03", want: "51.03"},
+		{parent: "51", relative: "3", want: "51.03"},
+		{parent: "51.03", relative: "03.2001.", want: "51.03.03.2001"},
+		{parent: "51.03.03", relative: "2001", want: "51.03.03.2001"},
+		{parent: "51", relative: "", wantErr: true},
+		{parent: "51.03.03.2001", relative: "1", wantErr: true},
+		{parent: "51.03", relative: "03.20011", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := MustParseKodeWilayah(tt.parent).Child(tt.relative)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s.Child(%q) = %s, want error", tt.parent, tt.relative, got)
+			}
+			continue
+		}
+		if err != nil || got.Dotted() != tt.want {
+			t.Errorf("%s.Child(%q) = %s, %v; want %s", tt.parent, tt.relative, got, err, tt.want)
+		}
+	}
+}
+
+func TestKodeWilayahQualify(t *testing.T) {
+	tests := []struct {
+		parent, value string
+		level         int
+		want          string
+	}{
+		{"51", "03", LevelKabupaten, "51.03"},
+		{"51", "51.03", LevelKabupaten, "51.03"},
+		{"51", "5103", LevelKabupaten, "51.03"},
+		{"51.03", "03", LevelKecamatan, "51.03.03"},
+		{"51.03", "51.03.03", LevelKecamatan, "51.03.03"},
+		{"51.03", "03.2001.", LevelDesa, "51.03.03.2001"},
+		{"51.03", "51.03.03.2001", LevelDesa, "51.03.03.2001"},
+		{"51.03", "5103032001", LevelDesa, "51.03.03.2001"},
+		// Satu segmen di bawah provinsi selalu kode relatif.
+		{"51", "52", LevelKabupaten, "51.52"},
+	}
+	for _, tt := range tests {
+		got, err := MustParseKodeWilayah(tt.parent).Qualify(tt.value, tt.level)
+		if err != nil || got.Dotted() != tt.want {
+			t.Errorf("%s.Qualify(%q, %d) = %s, %v; want %s", tt.parent, tt.value, tt.level, got, err, tt.want)
+		}
+	}
+}
+
+func TestKodeWilayahFormats(t *testing.T) {
+	k := MustParseKodeWilayah("51.03.03.2001")
+	tests := []struct {
+		name, got, want string
+	}{
+		{"Dotted", k.Dotted(), "51.03.03.2001"},
+		{"Compact", k.Compact(), "5103032001"},
+		{"Segment", k.Segment(), "2001"},
+		{"Parent kabupaten", k.Parent(LevelKabupaten).Dotted(), "51.03"},
+		{"Parent provinsi segment", k.Parent(LevelProvinsi).Segment(), "51"},
+		{"Parent above level", MustParseKodeWilayah("51").Parent(LevelDesa).Dotted(), "51"},
+		{"Format", k.Format("-"), "51-03-03-2001"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+	if !(KodeWilayah{}).IsZero() || k.IsZero() {
+		t.Error("IsZero mismatch")
+	}
+}
+
+func TestKodeWilayahJSON(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{`"51.03"`, "51.03"},
+		{`"5103"`, "51.03"},
+		{`5103`, "51.03"},
+		{`null`, ""},
+	}
+	for _, tt := range tests {
+		var k KodeWilayah
+		if err := json.Unmarshal([]byte(tt.in), &k); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", tt.in, err)
+		}
+		if k.Dotted() != tt.want {
+			t.Errorf("Unmarshal(%s) = %q, want %q", tt.in, k.Dotted(), tt.want)
+		}
+	}
+	out, err := json.Marshal(MustParseKodeWilayah("5103"))
+	if err != nil || string(out) != `"51.03"` {
+		t.Errorf("Marshal = %s, %v", out, err)
+	}
+	out, _ = json.Marshal(KodeWilayah{})
+	if string(out) != "null" {
+		t.Errorf("Marshal zero = %s, want null", out)
+	}
+}
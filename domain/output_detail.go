@@ -1,5 +1,10 @@
 package domain
 
+import (
+	"encoding/json"
+	"fmt"
+)
+
 // OutputDetail merepresentasikan struktur data detail output kegiatan.
 type OutputDetail struct {
 	Tahun         string  `json:"tahun" db:"tahun"`
@@ -14,7 +19,7 @@ type OutputDetail struct {
 	IDKegiatan    string  `json:"id_keg" db:"id_keg"`
 	NamaKegiatan  string  `json:"nama_kegiatan" db:"nama_kegiatan"`
 	KodeSumber    string  `json:"kode_sumber" db:"kode_sumber"`
-	Pagu          float64 `json:"pagu,string" db:"pagu"`
+	Pagu          Decimal `json:"pagu" db:"pagu"`
 	KodeOutput    string  `json:"kode_output" db:"kode_output"`
 	NoID          string  `json:"no_id" db:"no_id"`
 	NamaPaket     string  `json:"nama_paket" db:"nama_paket"`
@@ -24,16 +29,148 @@ type OutputDetail struct {
 	UraianOutput  string  `json:"uraian_output" db:"uraian_output"`
 	Volume        float64 `json:"volume,string" db:"volume"`
 	Satuan        string  `json:"satuan" db:"satuan"`
-	Nilai         float64 `json:"nilai,string" db:"nilai"`
-	Anggaran1     float64 `json:"anggaran1,string" db:"anggaran1"`
-	Anggaran2     float64 `json:"anggaran2,string" db:"anggaran2"`
-	Realisasi0    float64 `json:"realisasi0,string" db:"realisasi0"`
-	Realisasi1    float64 `json:"realisasi1,string" db:"realisasi1"`
-	Realisasi2    float64 `json:"realisasi2,string" db:"realisasi2"`
+	Nilai         Decimal `json:"nilai" db:"nilai"`
+	Anggaran1     Decimal `json:"anggaran1" db:"anggaran1"`
+	Anggaran2     Decimal `json:"anggaran2" db:"anggaran2"`
+	Realisasi0    Decimal `json:"realisasi0" db:"realisasi0"`
+	Realisasi1    Decimal `json:"realisasi1" db:"realisasi1"`
+	Realisasi2    Decimal `json:"realisasi2" db:"realisasi2"`
 	Fisik0        float64 `json:"fisik0,string" db:"fisik0"`
 	Fisik1        float64 `json:"fisik1,string" db:"fisik1"`
 	Fisik2        float64 `json:"fisik2,string" db:"fisik2"`
 	NamaPPTKD     string  `json:"namapptkd" db:"namapptkd"`
 	NIPPPTKD      string  `json:"nippptkd" db:"nippptkd"`
 	JabatanPPTKD  string  `json:"jbtpptkd" db:"jbtpptkd"`
+
+	// Kode wilayah persis seperti dari API, sebelum transformasi. Kolom
+	// kd_kab/kd_kec/kd_desa berisi kode hasil transformasi.
+	KodeKabupatenRaw string `json:"kd_kab_raw,omitempty" db:"kd_kab_raw"`
+	KodeKecamatanRaw string `json:"kd_kec_raw,omitempty" db:"kd_kec_raw"`
+	KodeDesaRaw      string `json:"kd_desa_raw,omitempty" db:"kd_desa_raw"`
+
+	// Coercions mencatat field angka yang formatnya tidak standar dan perlu
+	// dikoersi saat decoding, misal "pagu: locale (\"1.234,00\")".
+	// Tidak disimpan ke database.
+	Coercions []string `json:"-" db:"-"`
+}
+
+// outputDetailAlias dipakai agar UnmarshalJSON tidak memanggil dirinya sendiri.
+type outputDetailAlias OutputDetail
+
+// UnmarshalJSON membaca field angka lewat FlexNumber sehingga satu nilai yang
+// aneh (null, string kosong, format lokal) tidak menggagalkan decoding
+// seluruh record. Field yang dikoersi dicatat di Coercions.
+func (d *OutputDetail) UnmarshalJSON(data []byte) error {
+	aux := struct {
+		*outputDetailAlias
+		Pagu       FlexNumber `json:"pagu"`
+		Volume     FlexNumber `json:"volume"`
+		Nilai      FlexNumber `json:"nilai"`
+		Anggaran1  FlexNumber `json:"anggaran1"`
+		Anggaran2  FlexNumber `json:"anggaran2"`
+		Realisasi0 FlexNumber `json:"realisasi0"`
+		Realisasi1 FlexNumber `json:"realisasi1"`
+		Realisasi2 FlexNumber `json:"realisasi2"`
+		Fisik0     FlexNumber `json:"fisik0"`
+		Fisik1     FlexNumber `json:"fisik1"`
+		Fisik2     FlexNumber `json:"fisik2"`
+	}{outputDetailAlias: (*outputDetailAlias)(d)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	// Payload API tidak memuat *_raw, sehingga kode aslinya diambil dari
+	// kd_kab/kd_kec/kd_desa. Data yang sudah pernah diproses (misal hasil
+	// replay) membawa *_raw sendiri dan tidak ditimpa.
+	if d.KodeKabupatenRaw == "" {
+		d.KodeKabupatenRaw = d.KodeKabupaten
+	}
+	if d.KodeKecamatanRaw == "" {
+		d.KodeKecamatanRaw = d.KodeKecamatan
+	}
+	if d.KodeDesaRaw == "" {
+		d.KodeDesaRaw = d.KodeDesa
+	}
+
+	d.Coercions = nil
+	money := []struct {
+		name string
+		src  FlexNumber
+		dst  *Decimal
+	}{
+		{"pagu", aux.Pagu, &d.Pagu},
+		{"nilai", aux.Nilai, &d.Nilai},
+		{"anggaran1", aux.Anggaran1, &d.Anggaran1},
+		{"anggaran2", aux.Anggaran2, &d.Anggaran2},
+		{"realisasi0", aux.Realisasi0, &d.Realisasi0},
+		{"realisasi1", aux.Realisasi1, &d.Realisasi1},
+		{"realisasi2", aux.Realisasi2, &d.Realisasi2},
+	}
+	for _, f := range money {
+		*f.dst = f.src.Number
+		d.noteCoercion(f.name, f.src)
+	}
+
+	quantities := []struct {
+		name string
+		src  FlexNumber
+		dst  *float64
+	}{
+		{"volume", aux.Volume, &d.Volume},
+		{"fisik0", aux.Fisik0, &d.Fisik0},
+		{"fisik1", aux.Fisik1, &d.Fisik1},
+		{"fisik2", aux.Fisik2, &d.Fisik2},
+	}
+	for _, f := range quantities {
+		*f.dst = f.src.Float64()
+		d.noteCoercion(f.name, f.src)
+	}
+
+	return nil
+}
+
+func (d *OutputDetail) noteCoercion(field string, n FlexNumber) {
+	// Key yang tidak ada di payload tidak melewati UnmarshalJSON, sehingga
+	// tidak ikut tercatat di sini.
+	if !n.Coerced() {
+		return
+	}
+	d.Coercions = append(d.Coercions, fmt.Sprintf("%s: %s (%q)", field, n.Coercion, n.Raw))
+}
+
+// CoercionReport mengembalikan identitas record beserta catatan koersinya,
+// dipakai fetcher untuk logging.
+func (d OutputDetail) CoercionReport() (string, []string) {
+	return fmt.Sprintf("id_keg=%s, no_id=%s", d.IDKegiatan, d.NoID), d.Coercions
+}
+
+// QualifiedKode mengembalikan kode lengkap kabupaten, kecamatan dan desa,
+// dihitung dari kolom *_raw jika terisi. Hasilnya sama baik kode sudah
+// ditransformasi maupun belum. kd_desa dari API sudah memuat kode kecamatan
+// ("03.2001."), sehingga diturunkan dari kode kabupaten.
+func (d OutputDetail) QualifiedKode() (kab, kec, desa KodeWilayah, err error) {
+	prov, err := ParseKodeWilayah(d.KodeProvinsi)
+	if err != nil {
+		return
+	}
+	if kab, err = prov.Qualify(FirstNonEmpty(d.KodeKabupatenRaw, d.KodeKabupaten), LevelKabupaten); err != nil {
+		return
+	}
+	if kec, err = kab.Qualify(FirstNonEmpty(d.KodeKecamatanRaw, d.KodeKecamatan), LevelKecamatan); err != nil {
+		return
+	}
+	desa, err = kab.Qualify(FirstNonEmpty(d.KodeDesaRaw, d.KodeDesa), LevelDesa)
+	return
+}
+
+// FirstNonEmpty mengembalikan nilai pertama yang tidak kosong, misal kode
+// asli API sebelum kode hasil transformasi.
+func FirstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
 }
@@ -0,0 +1,45 @@
+package domain
+
+// Totals adalah jumlah record dan total nilai uang output detail untuk satu
+// wilayah, dipakai untuk rekonsiliasi antara API dan database.
+type Totals struct {
+	Count      int64   `json:"count" db:"count"`
+	Pagu       Decimal `json:"pagu" db:"pagu"`
+	Nilai      Decimal `json:"nilai" db:"nilai"`
+	Anggaran1  Decimal `json:"anggaran1" db:"anggaran1"`
+	Anggaran2  Decimal `json:"anggaran2" db:"anggaran2"`
+	Realisasi0 Decimal `json:"realisasi0" db:"realisasi0"`
+	Realisasi1 Decimal `json:"realisasi1" db:"realisasi1"`
+	Realisasi2 Decimal `json:"realisasi2" db:"realisasi2"`
+}
+
+// Add menambahkan satu record ke total.
+func (t *Totals) Add(d OutputDetail) {
+	t.Count++
+	t.Pagu = t.Pagu.Add(d.Pagu)
+	t.Nilai = t.Nilai.Add(d.Nilai)
+	t.Anggaran1 = t.Anggaran1.Add(d.Anggaran1)
+	t.Anggaran2 = t.Anggaran2.Add(d.Anggaran2)
+	t.Realisasi0 = t.Realisasi0.Add(d.Realisasi0)
+	t.Realisasi1 = t.Realisasi1.Add(d.Realisasi1)
+	t.Realisasi2 = t.Realisasi2.Add(d.Realisasi2)
+}
+
+// Amounts mengembalikan semua field uang beserta nama kolomnya, berurutan.
+func (t Totals) Amounts() []NamedAmount {
+	return []NamedAmount{
+		{"pagu", t.Pagu},
+		{"nilai", t.Nilai},
+		{"anggaran1", t.Anggaran1},
+		{"anggaran2", t.Anggaran2},
+		{"realisasi0", t.Realisasi0},
+		{"realisasi1", t.Realisasi1},
+		{"realisasi2", t.Realisasi2},
+	}
+}
+
+// NamedAmount adalah satu nilai uang beserta nama kolomnya.
+type NamedAmount struct {
+	Name   string
+	Amount Decimal
+}
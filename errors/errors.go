@@ -1,6 +1,10 @@
 package errors
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 // ErrAPICallFailed adalah error ketika panggilan ke API eksternal gagal.
 type ErrAPICallFailed struct {
@@ -25,3 +29,29 @@ func (e *ErrDBOperationFailed) Error() string {
 func (e *ErrDBOperationFailed) Unwrap() error {
 	return e.Err
 }
+
+// ErrInvalidConfig adalah error ketika konfigurasi tidak valid. Semua masalah
+// dikumpulkan sekaligus agar bisa diperbaiki dalam satu kali jalan.
+type ErrInvalidConfig struct {
+	Problems []string
+}
+
+func (e *ErrInvalidConfig) Error() string {
+	return fmt.Sprintf("invalid configuration (%d problems):\n  - %s", len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+// ErrUpstreamUnavailable adalah error ketika circuit breaker fetcher sudah
+// menghabiskan budget waktu terbukanya, sehingga sinkronisasi dihentikan.
+type ErrUpstreamUnavailable struct {
+	Failures int           // jumlah kegagalan beruntun terakhir
+	Downtime time.Duration // total waktu circuit terbuka
+	LastErr  error
+}
+
+func (e *ErrUpstreamUnavailable) Error() string {
+	return fmt.Sprintf("upstream API unavailable: circuit open for %s after %d consecutive failures (last error: %v)", e.Downtime.Round(time.Second), e.Failures, e.LastErr)
+}
+
+func (e *ErrUpstreamUnavailable) Unwrap() error {
+	return e.LastErr
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/aryadiwwt/synctodb/domain"
+	"github.com/aryadiwwt/synctodb/exporter"
+	"github.com/aryadiwwt/synctodb/redact"
+	"github.com/aryadiwwt/synctodb/storer"
+)
+
+// runExport menulis output detail yang tersimpan ke CSV atau XLSX dan
+// mengembalikan exit code.
+func runExport(logger *log.Logger, redactor *redact.Redactor, args []string) int {
+	// Hasil bisa ditulis ke stdout, jadi log dipindah ke stderr.
+	logger.SetOutput(redactor.Writer(os.Stderr))
+
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	configPath, overrides := configFlags(fs)
+	format := fs.String("format", exporter.FormatCSV, "Format output: csv atau xlsx")
+	outPath := fs.String("out", "", "Path file output (wajib untuk xlsx; csv default ke stdout)")
+	tahun := fs.String("tahun", "", "Tahun anggaran (default: api.tahun)")
+	prov := fs.String("prov", "", "Kode provinsi, misal 51")
+	kab := fs.String("kab", "", "Kode kabupaten, relatif (03) atau lengkap (51.03)")
+	kec := fs.String("kec", "", "Kode kecamatan, relatif (03) atau lengkap (51.03.03)")
+	desa := fs.String("desa", "", "Kode desa, relatif (2001) atau lengkap (51.03.03.2001)")
+	sumber := fs.String("sumber", "", "Daftar kode_sumber yang dipisahkan koma, misal DDS,ADD")
+	fs.Parse(args)
+
+	if *format != exporter.FormatCSV && *format != exporter.FormatXLSX {
+		fmt.Fprintf(os.Stderr, "format %q tidak dikenal (gunakan csv atau xlsx)\n", *format)
+		return 2
+	}
+	if *format == exporter.FormatXLSX && *outPath == "" {
+		fmt.Fprintln(os.Stderr, "-out wajib diisi untuk format xlsx")
+		return 2
+	}
+	wilayah, err := exportWilayah(*prov, *kab, *kec, *desa)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	cfg := loadConfig(logger, redactor, *configPath, *overrides)
+	filter := storer.OutputDetailFilter{
+		Tahun:   *tahun,
+		Wilayah: wilayah,
+	}
+	if filter.Tahun == "" {
+		filter.Tahun = fmt.Sprint(cfg.API.Tahun)
+	}
+	for _, s := range strings.Split(*sumber, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			filter.KodeSumber = append(filter.KodeSumber, s)
+		}
+	}
+
+	db := openDatabase(logger, redactor, cfg)
+	defer db.Close()
+
+	var out io.Writer = os.Stdout
+	var f *os.File
+	if *outPath != "" {
+		if f, err = os.Create(*outPath); err != nil {
+			logger.Printf("FATAL: gagal membuat file output: %v", err)
+			return 1
+		}
+		out = f
+	}
+	// fail menutup dan menghapus file output yang belum lengkap agar tidak
+	// tertinggal file terpotong yang terlihat seperti hasil ekspor.
+	fail := func(format string, args ...interface{}) int {
+		logger.Printf(format, args...)
+		if f != nil {
+			f.Close()
+			if err := os.Remove(*outPath); err != nil {
+				logger.Printf("WARNING: gagal menghapus file output yang tidak lengkap %s: %v", *outPath, err)
+			}
+		}
+		return 1
+	}
+
+	var w exporter.Writer
+	if *format == exporter.FormatXLSX {
+		if w, err = exporter.NewXLSXWriter(out); err != nil {
+			return fail("FATAL: %v", err)
+		}
+	} else {
+		w = exporter.NewCSVWriter(out)
+	}
+
+	n, err := exporter.Export(context.Background(), storer.NewOutputDetailReader(db, redactor), filter, w)
+	if err != nil {
+		return fail("Ekspor gagal: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return fail("Ekspor gagal: %v", err)
+	}
+	if f != nil {
+		if err := f.Close(); err != nil {
+			return fail("Ekspor gagal: %v", err)
+		}
+	}
+
+	logger.Printf("Ekspor selesai: %d baris.", n)
+	return 0
+}
+
+// exportWilayah menggabungkan flag kode wilayah menjadi satu KodeWilayah.
+// Kode relatif dilengkapi dengan kode induknya; kode lengkap boleh dipakai
+// tanpa flag induk.
+func exportWilayah(prov, kab, kec, desa string) (domain.KodeWilayah, error) {
+	var k domain.KodeWilayah
+	levels := []struct {
+		flag  string
+		value string
+		level int
+	}{
+		{"-prov", prov, domain.LevelProvinsi},
+		{"-kab", kab, domain.LevelKabupaten},
+		{"-kec", kec, domain.LevelKecamatan},
+		{"-desa", desa, domain.LevelDesa},
+	}
+	for _, l := range levels {
+		if l.value == "" {
+			continue
+		}
+		var next domain.KodeWilayah
+		var err error
+		if k.IsZero() {
+			next, err = domain.ParseKodeWilayah(l.value)
+		} else {
+			next, err = k.Qualify(l.value, l.level)
+		}
+		if err != nil {
+			return domain.KodeWilayah{}, fmt.Errorf("%s: %v", l.flag, err)
+		}
+		if next.Level() != l.level {
+			return domain.KodeWilayah{}, fmt.Errorf("%s: %q bukan kode %s lengkap (isi juga flag induknya)", l.flag, l.value, strings.TrimPrefix(l.flag, "-"))
+		}
+		k = next
+	}
+	return k, nil
+}
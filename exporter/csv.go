@@ -0,0 +1,48 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"io"
+
+	"github.com/aryadiwwt/synctodb/domain"
+)
+
+type csvWriter struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+// NewCSVWriter menulis satu baris CSV per record dengan header nama kolom.
+// Nilai uang ditulis sebagai desimal persis, misal "1234567.50".
+func NewCSVWriter(w io.Writer) Writer {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) writeHeader() error {
+	if c.wroteHeader {
+		return nil
+	}
+	c.wroteHeader = true
+	return c.w.Write(header())
+}
+
+func (c *csvWriter) Write(d domain.OutputDetail) error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	vals := values(d)
+	record := make([]string, len(vals))
+	for i, v := range vals {
+		record[i] = formatText(v)
+	}
+	return c.w.Write(record)
+}
+
+// Close menulis header jika belum ada baris sama sekali, lalu flush.
+func (c *csvWriter) Close() error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
@@ -0,0 +1,92 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/aryadiwwt/synctodb/domain"
+	"github.com/aryadiwwt/synctodb/storer"
+)
+
+// Format ekspor yang didukung.
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// Writer menulis baris output detail satu per satu. Close wajib dipanggil
+// untuk menyelesaikan file (subtotal terakhir, flush).
+type Writer interface {
+	Write(d domain.OutputDetail) error
+	Close() error
+}
+
+// Export membaca baris dari reader sesuai filter dan menulisnya ke w, lalu
+// mengembalikan jumlah baris yang ditulis. w tidak ditutup oleh Export.
+func Export(ctx context.Context, r storer.OutputDetailReader, filter storer.OutputDetailFilter, w Writer) (int, error) {
+	n := 0
+	err := r.StreamOutputDetails(ctx, filter, func(d domain.OutputDetail) error {
+		if err := w.Write(d); err != nil {
+			return fmt.Errorf("gagal menulis baris %d: %w", n+1, err)
+		}
+		n++
+		return nil
+	})
+	return n, err
+}
+
+// column adalah satu kolom ekspor, diambil dari tag db domain.OutputDetail
+// sehingga urutannya sama dengan tabel.
+type column struct {
+	name  string
+	index int
+}
+
+var columns = func() []column {
+	t := reflect.TypeOf(domain.OutputDetail{})
+	var cols []column
+	for _, name := range storer.Columns[domain.OutputDetail]() {
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).Tag.Get("db") == name {
+				cols = append(cols, column{name: name, index: i})
+				break
+			}
+		}
+	}
+	return cols
+}()
+
+func header() []string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.name
+	}
+	return names
+}
+
+// values mengembalikan nilai setiap kolom; uang tetap berupa domain.Decimal
+// agar penulis bisa memilih representasinya.
+func values(d domain.OutputDetail) []interface{} {
+	v := reflect.ValueOf(d)
+	out := make([]interface{}, len(columns))
+	for i, c := range columns {
+		out[i] = v.Field(c.index).Interface()
+	}
+	return out
+}
+
+// formatText mengubah nilai kolom menjadi teks tanpa kehilangan presisi.
+func formatText(v interface{}) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case domain.Decimal:
+		return x.String()
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	default:
+		return fmt.Sprint(x)
+	}
+}
@@ -0,0 +1,230 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aryadiwwt/synctodb/domain"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// maxSheetName adalah batas panjang nama sheet Excel.
+const maxSheetName = 31
+
+// xlsxWriter menulis satu sheet per kecamatan memakai StreamWriter excelize,
+// sehingga baris tidak ditahan di memori. Baris harus datang terurut per
+// kecamatan lalu desa (seperti OutputDetailReader). Setiap pergantian desa
+// diberi baris subtotal, dan setiap sheet diakhiri baris total kecamatan.
+type xlsxWriter struct {
+	out  io.Writer
+	file *excelize.File
+
+	headerStyle int
+	moneyStyle  int
+	totalStyle  int
+	totalMoney  int
+
+	sheets   map[string]bool
+	stream   *excelize.StreamWriter
+	row      int
+	kec      string
+	desa     string
+	desaName string
+
+	desaTotals []domain.Decimal // per kolom, hanya kolom uang
+	kecTotals  []domain.Decimal
+}
+
+// NewXLSXWriter membuat Writer XLSX yang menulis workbook ke w saat Close.
+func NewXLSXWriter(w io.Writer) (Writer, error) {
+	f := excelize.NewFile()
+	x := &xlsxWriter{out: w, file: f, sheets: make(map[string]bool)}
+
+	styles := []struct {
+		dst   *int
+		style *excelize.Style
+	}{
+		{&x.headerStyle, &excelize.Style{Font: &excelize.Font{Bold: true}}},
+		{&x.moneyStyle, &excelize.Style{NumFmt: 4}}, // #,##0.00
+		{&x.totalStyle, &excelize.Style{Font: &excelize.Font{Bold: true}}},
+		{&x.totalMoney, &excelize.Style{Font: &excelize.Font{Bold: true}, NumFmt: 4}},
+	}
+	for _, s := range styles {
+		id, err := f.NewStyle(s.style)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("gagal membuat style xlsx: %w", err)
+		}
+		*s.dst = id
+	}
+	return x, nil
+}
+
+func (x *xlsxWriter) Write(d domain.OutputDetail) error {
+	kec, desa := wilayahKeys(d)
+
+	if x.stream == nil || kec != x.kec {
+		if err := x.finishSheet(); err != nil {
+			return err
+		}
+		if err := x.startSheet(kec, d.NamaKecamatan); err != nil {
+			return err
+		}
+	} else if desa != x.desa {
+		if err := x.writeSubtotal(); err != nil {
+			return err
+		}
+	}
+	x.desa, x.desaName = desa, d.NamaDesa
+
+	vals := values(d)
+	cells := make([]interface{}, len(vals))
+	for i, v := range vals {
+		if dec, ok := v.(domain.Decimal); ok {
+			x.desaTotals[i] = x.desaTotals[i].Add(dec)
+			x.kecTotals[i] = x.kecTotals[i].Add(dec)
+			cells[i] = excelize.Cell{StyleID: x.moneyStyle, Value: dec.Float64()}
+			continue
+		}
+		cells[i] = v
+	}
+	return x.setRow(cells)
+}
+
+// Close menyelesaikan sheet terakhir dan menulis workbook.
+func (x *xlsxWriter) Close() error {
+	defer x.file.Close()
+
+	if x.stream == nil {
+		// Tidak ada data: tetap tulis header di sheet bawaan.
+		if err := x.startSheet("", ""); err != nil {
+			return err
+		}
+	}
+	if err := x.finishSheet(); err != nil {
+		return err
+	}
+	if _, err := x.file.WriteTo(x.out); err != nil {
+		return fmt.Errorf("gagal menulis xlsx: %w", err)
+	}
+	return nil
+}
+
+func (x *xlsxWriter) startSheet(kec, nama string) error {
+	name := x.sheetName(kec, nama)
+	if len(x.sheets) == 0 {
+		// Sheet pertama memakai ulang "Sheet1" bawaan excelize.
+		if err := x.file.SetSheetName("Sheet1", name); err != nil {
+			return err
+		}
+	} else if _, err := x.file.NewSheet(name); err != nil {
+		return err
+	}
+	x.sheets[name] = true
+
+	stream, err := x.file.NewStreamWriter(name)
+	if err != nil {
+		return err
+	}
+	x.stream, x.row, x.kec, x.desa = stream, 0, kec, ""
+	x.desaTotals = make([]domain.Decimal, len(columns))
+	x.kecTotals = make([]domain.Decimal, len(columns))
+
+	cols := header()
+	cells := make([]interface{}, len(cols))
+	for i, c := range cols {
+		cells[i] = excelize.Cell{StyleID: x.headerStyle, Value: c}
+	}
+	return x.setRow(cells)
+}
+
+// finishSheet menulis subtotal desa terakhir dan total kecamatan, lalu
+// mem-flush sheet. StreamWriter excelize harus selesai sebelum sheet lain
+// ditulis.
+func (x *xlsxWriter) finishSheet() error {
+	if x.stream == nil {
+		return nil
+	}
+	if x.desa != "" {
+		if err := x.writeSubtotal(); err != nil {
+			return err
+		}
+		label := strings.TrimSpace("Total kecamatan " + x.kec)
+		if err := x.writeTotals(label, x.kecTotals); err != nil {
+			return err
+		}
+	}
+	err := x.stream.Flush()
+	x.stream = nil
+	return err
+}
+
+func (x *xlsxWriter) writeSubtotal() error {
+	label := strings.TrimSpace(fmt.Sprintf("Subtotal desa %s %s", x.desa, x.desaName))
+	err := x.writeTotals(label, x.desaTotals)
+	x.desaTotals = make([]domain.Decimal, len(columns))
+	return err
+}
+
+// writeTotals menulis label di kolom pertama dan total di kolom uang.
+func (x *xlsxWriter) writeTotals(label string, totals []domain.Decimal) error {
+	cells := make([]interface{}, len(columns))
+	cells[0] = excelize.Cell{StyleID: x.totalStyle, Value: label}
+	zero := values(domain.OutputDetail{})
+	for i, v := range zero {
+		if _, ok := v.(domain.Decimal); ok {
+			cells[i] = excelize.Cell{StyleID: x.totalMoney, Value: totals[i].Float64()}
+		}
+	}
+	return x.setRow(cells)
+}
+
+func (x *xlsxWriter) setRow(cells []interface{}) error {
+	x.row++
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	return x.stream.SetRow(cell, cells)
+}
+
+// sheetName membuat nama sheet unik dari kode dan nama kecamatan, tanpa
+// karakter yang dilarang Excel dan maksimal 31 karakter.
+func (x *xlsxWriter) sheetName(kec, nama string) string {
+	base := strings.TrimSpace(kec + " " + nama)
+	if base == "" {
+		base = "Data"
+	}
+	base = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '-'
+		}
+		return r
+	}, base)
+	if len([]rune(base)) > maxSheetName {
+		base = string([]rune(base)[:maxSheetName])
+	}
+
+	name := base
+	for i := 2; x.sheets[name]; i++ {
+		suffix := fmt.Sprintf(" (%d)", i)
+		r := []rune(base)
+		if len(r)+len(suffix) > maxSheetName {
+			r = r[:maxSheetName-len(suffix)]
+		}
+		name = strings.TrimRight(string(r), " ") + suffix
+	}
+	return name
+}
+
+// wilayahKeys mengembalikan kode lengkap kecamatan dan desa untuk
+// pengelompokan, apa pun profil transformasi yang dipakai saat sync.
+func wilayahKeys(d domain.OutputDetail) (kec, desa string) {
+	_, k, ds, err := d.QualifiedKode()
+	if err != nil {
+		return d.KodeProvinsi + "." + d.KodeKecamatan, d.KodeDesa
+	}
+	return k.Dotted(), ds.Dotted()
+}
@@ -0,0 +1,189 @@
+package exporter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/aryadiwwt/synctodb/domain"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// xlsxRow membuat baris dengan kode relatif seperti dari API.
+func xlsxRow(t *testing.T, kec, namaKec, desa, namaDesa, pagu string) domain.OutputDetail {
+	t.Helper()
+	p, err := domain.ParseDecimal(pagu)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return domain.OutputDetail{
+		Tahun:         "2024",
+		KodeProvinsi:  "51",
+		KodeKabupaten: "03",
+		KodeKecamatan: kec,
+		NamaKecamatan: namaKec,
+		KodeDesa:      kec + "." + desa + ".",
+		NamaDesa:      namaDesa,
+		IDKegiatan:    "K1",
+		Pagu:          p,
+	}
+}
+
+func columnIndex(t *testing.T, name string) int {
+	t.Helper()
+	for i, c := range header() {
+		if c == name {
+			return i
+		}
+	}
+	t.Fatalf("kolom %s tidak ada", name)
+	return -1
+}
+
+func TestXLSXWriterSheets(t *testing.T) {
+	rows := []domain.OutputDetail{
+		xlsxRow(t, "01", "KUTA SELATAN", "2001", "PECATU", "100.50"),
+		xlsxRow(t, "01", "KUTA SELATAN", "2001", "PECATU", "200.25"),
+		xlsxRow(t, "01", "KUTA SELATAN", "2002", "UNGASAN", "300"),
+		xlsxRow(t, "03", "KUTA UTARA", "2001", "DALUNG", "50"),
+	}
+
+	var buf bytes.Buffer
+	w, err := NewXLSXWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range rows {
+		if err := w.Write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	wantSheets := []string{"51.03.01 KUTA SELATAN", "51.03.03 KUTA UTARA"}
+	if got := f.GetSheetList(); strings.Join(got, "|") != strings.Join(wantSheets, "|") {
+		t.Fatalf("sheet = %q, want %q", got, wantSheets)
+	}
+
+	pagu := columnIndex(t, "pagu")
+	tests := []struct {
+		sheet string
+		want  [][2]string // label kolom A (atau tahun untuk baris data) dan pagu
+	}{
+		{
+			sheet: wantSheets[0],
+			want: [][2]string{
+				{"tahun", "pagu"},
+				{"2024", "100.5"},
+				{"2024", "200.25"},
+				{"Subtotal desa 51.03.01.2001 PECATU", "300.75"},
+				{"2024", "300"},
+				{"Subtotal desa 51.03.01.2002 UNGASAN", "300"},
+				{"Total kecamatan 51.03.01", "600.75"},
+			},
+		},
+		{
+			sheet: wantSheets[1],
+			want: [][2]string{
+				{"tahun", "pagu"},
+				{"2024", "50"},
+				{"Subtotal desa 51.03.03.2001 DALUNG", "50"},
+				{"Total kecamatan 51.03.03", "50"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sheet, func(t *testing.T) {
+			got, err := f.GetRows(tt.sheet, excelize.Options{RawCellValue: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("%d baris, want %d: %q", len(got), len(tt.want), got)
+			}
+			for i, want := range tt.want {
+				row := got[i]
+				var cell string
+				if pagu < len(row) {
+					cell = row[pagu]
+				}
+				if row[0] != want[0] || cell != want[1] {
+					t.Errorf("baris %d = (%q, %q), want (%q, %q)", i+1, row[0], cell, want[0], want[1])
+				}
+			}
+		})
+	}
+}
+
+func TestXLSXWriterEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewXLSXWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := f.GetRows("Data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0][0] != "tahun" {
+		t.Errorf("workbook kosong harus berisi header saja, got %q", rows)
+	}
+}
+
+func TestXLSXSheetName(t *testing.T) {
+	long := strings.Repeat("KECAMATAN PANJANG ", 3)
+	tests := []struct {
+		name     string
+		existing []string
+		kec      string
+		nama     string
+		want     string
+	}{
+		{name: "kode dan nama", kec: "51.03.01", nama: "KUTA SELATAN", want: "51.03.01 KUTA SELATAN"},
+		{name: "kosong", want: "Data"},
+		{name: "karakter terlarang", kec: "51.03.01", nama: "A/B: C*D?", want: "51.03.01 A-B- C-D-"},
+		{name: "dipotong 31 karakter", kec: "51.03.01", nama: long, want: "51.03.01 KECAMATAN PANJANG KECA"},
+		{name: "duplikat", existing: []string{"51.03.01 KUTA"}, kec: "51.03.01", nama: "KUTA", want: "51.03.01 KUTA (2)"},
+		{name: "duplikat kedua", existing: []string{"51.03.01 KUTA", "51.03.01 KUTA (2)"}, kec: "51.03.01", nama: "KUTA", want: "51.03.01 KUTA (3)"},
+		{
+			name:     "duplikat panjang tetap 31 karakter",
+			existing: []string{"51.03.01 KECAMATAN PANJANG KECA"},
+			kec:      "51.03.01",
+			nama:     long,
+			want:     "51.03.01 KECAMATAN PANJANG (2)",
+		},
+		{name: "nama multibyte", kec: "01", nama: strings.Repeat("é", 40), want: "01 " + strings.Repeat("é", 28)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x := &xlsxWriter{sheets: make(map[string]bool)}
+			for _, s := range tt.existing {
+				x.sheets[s] = true
+			}
+			got := x.sheetName(tt.kec, tt.nama)
+			if got != tt.want {
+				t.Errorf("sheetName() = %q, want %q", got, tt.want)
+			}
+			if n := len([]rune(got)); n > maxSheetName {
+				t.Errorf("panjang nama sheet %d melebihi %d", n, maxSheetName)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	customErrors "github.com/aryadiwwt/synctodb/errors"
+)
+
+// BreakerSettings mengatur CircuitBreaker.
+type BreakerSettings struct {
+	// FailureThreshold adalah jumlah kegagalan beruntun yang membuka circuit.
+	FailureThreshold int
+	// OpenDuration adalah jeda sebelum satu request percobaan (half-open).
+	OpenDuration time.Duration
+	// Budget adalah total waktu circuit boleh terbuka dalam satu run sebelum
+	// run dihentikan dengan ErrUpstreamUnavailable.
+	Budget time.Duration
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreaker menghentikan sementara request ke API setelah
+// FailureThreshold kegagalan beruntun. Selama terbuka, Allow menunggu
+// OpenDuration lalu meloloskan satu request percobaan; jika berhasil circuit
+// tertutup kembali dan run berlanjut, jika gagal circuit terbuka lagi. Hanya
+// hasil request percobaan yang bisa menutup circuit: request yang sudah
+// berjalan sebelum circuit terbuka dan baru selesai kemudian diabaikan. Setelah
+// total waktu terbuka melewati Budget, Allow mengembalikan
+// *errors.ErrUpstreamUnavailable.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	settings  BreakerSettings
+	state     breakerState
+	failures  int
+	openedAt  time.Time
+	downtime  time.Duration // akumulasi waktu terbuka yang sudah selesai
+	probing   bool          // request percobaan sedang berjalan
+	lastErr   error
+	exhausted bool
+	log       *log.Logger
+}
+
+// NewCircuitBreaker membuat CircuitBreaker dalam keadaan tertutup.
+func NewCircuitBreaker(s BreakerSettings, l *log.Logger) *CircuitBreaker {
+	if s.FailureThreshold < 1 {
+		s.FailureThreshold = 1
+	}
+	if l == nil {
+		l = log.Default()
+	}
+	return &CircuitBreaker{settings: s, log: l}
+}
+
+// Allow menunggu sampai request boleh dikirim. probe true berarti request ini
+// adalah request percobaan half-open dan harus diteruskan ke Record atau
+// Release. Error dikembalikan jika ctx selesai atau budget sudah habis.
+func (b *CircuitBreaker) Allow(ctx context.Context) (probe bool, err error) {
+	for {
+		b.mu.Lock()
+		if b.exhausted {
+			err := b.unavailable()
+			b.mu.Unlock()
+			return false, err
+		}
+
+		var wait time.Duration
+		switch b.state {
+		case breakerClosed:
+			b.mu.Unlock()
+			return false, nil
+		case breakerOpen:
+			open := time.Since(b.openedAt)
+			if b.downtime+open >= b.settings.Budget {
+				b.exhausted = true
+				b.downtime += open
+				b.log.Printf("Circuit breaker: API tidak pulih dalam budget %s, sinkronisasi dihentikan.", b.settings.Budget)
+				err := b.unavailable()
+				b.mu.Unlock()
+				return false, err
+			}
+			if open >= b.settings.OpenDuration {
+				b.state = breakerHalfOpen
+				b.probing = true
+				b.log.Println("Circuit breaker: half-open, mengirim request percobaan...")
+				b.mu.Unlock()
+				return true, nil
+			}
+			wait = b.settings.OpenDuration - open
+			if rest := b.settings.Budget - b.downtime - open; rest < wait {
+				wait = rest
+			}
+		case breakerHalfOpen:
+			// Hanya satu request percobaan; yang lain menunggu hasilnya.
+			wait = 100 * time.Millisecond
+		}
+		b.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Record mencatat hasil satu request yang diloloskan Allow, dengan probe dari
+// Allow. err nil berarti berhasil; hanya error koneksi dan status 5xx yang
+// sebaiknya dihitung gagal. Hasil non-percobaan yang tiba saat circuit
+// terbuka atau half-open berasal dari request sebelum circuit terbuka: sukses
+// diabaikan, kegagalan hanya dicatat untuk laporan.
+func (b *CircuitBreaker) Record(probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe && b.state == breakerHalfOpen && b.probing {
+		b.downtime += time.Since(b.openedAt)
+		if err == nil {
+			b.state, b.failures, b.probing = breakerClosed, 0, false
+			b.log.Printf("Circuit breaker: API pulih, circuit ditutup kembali (total terbuka %s).", b.downtime.Round(time.Second))
+			return
+		}
+		// Percobaan gagal: buka lagi tanpa menghitung ulang jeda sebelumnya.
+		b.failures++
+		b.lastErr = err
+		b.state, b.openedAt, b.probing = breakerOpen, time.Now(), false
+		b.log.Printf("Circuit breaker: request percobaan gagal (%v), circuit terbuka lagi selama %s.", err, b.settings.OpenDuration)
+		return
+	}
+
+	if err == nil {
+		if b.state == breakerClosed {
+			b.failures = 0
+		}
+		return
+	}
+
+	b.failures++
+	b.lastErr = err
+	if b.state == breakerClosed && b.failures >= b.settings.FailureThreshold {
+		b.state, b.openedAt = breakerOpen, time.Now()
+		b.log.Printf("Circuit breaker: %d kegagalan beruntun (terakhir: %v), circuit terbuka selama %s.", b.failures, err, b.settings.OpenDuration)
+	}
+}
+
+// Release dipanggil jika request yang diloloskan Allow batal sebelum ada
+// hasil (misal ctx dibatalkan). Request percobaan yang batal tidak dihitung,
+// sehingga request berikutnya langsung menjadi percobaan baru.
+func (b *CircuitBreaker) Release(probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if probe && b.state == breakerHalfOpen && b.probing {
+		b.state, b.probing = breakerOpen, false
+	}
+}
+
+// State mengembalikan keadaan circuit: closed, open atau half-open.
+func (b *CircuitBreaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state.String()
+}
+
+func (b *CircuitBreaker) unavailable() error {
+	return &customErrors.ErrUpstreamUnavailable{Failures: b.failures, Downtime: b.downtime, LastErr: b.lastErr}
+}
+
+// breakerFailure mengubah status HTTP menjadi error untuk Record: 5xx
+// dianggap kegagalan upstream, status lain bukan.
+func breakerFailure(status int) error {
+	if status >= 500 {
+		return fmt.Errorf("status %d", status)
+	}
+	return nil
+}
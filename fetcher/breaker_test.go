@@ -0,0 +1,168 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	customErrors "github.com/aryadiwwt/synctodb/errors"
+)
+
+var errUpstream = errors.New("status 502")
+
+// breakerStep adalah satu langkah skenario: op "allow" memanggil Allow dan
+// membandingkan probe; "record" dan "release" meneruskan probe dan err.
+type breakerStep struct {
+	op        string
+	probe     bool
+	err       error
+	wantState string
+}
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []breakerStep
+	}{
+		{
+			name: "ambang kegagalan membuka circuit",
+			steps: []breakerStep{
+				{op: "record", err: errUpstream, wantState: "closed"},
+				{op: "record", err: errUpstream, wantState: "open"},
+			},
+		},
+		{
+			name: "sukses mereset hitungan kegagalan",
+			steps: []breakerStep{
+				{op: "record", err: errUpstream, wantState: "closed"},
+				{op: "record", wantState: "closed"},
+				{op: "record", err: errUpstream, wantState: "closed"},
+			},
+		},
+		{
+			name: "percobaan sukses menutup circuit",
+			steps: []breakerStep{
+				{op: "record", err: errUpstream},
+				{op: "record", err: errUpstream, wantState: "open"},
+				{op: "allow", probe: true, wantState: "half-open"},
+				{op: "record", probe: true, wantState: "closed"},
+				{op: "allow", probe: false, wantState: "closed"},
+			},
+		},
+		{
+			name: "percobaan gagal membuka lagi",
+			steps: []breakerStep{
+				{op: "record", err: errUpstream},
+				{op: "record", err: errUpstream, wantState: "open"},
+				{op: "allow", probe: true, wantState: "half-open"},
+				{op: "record", probe: true, err: errUpstream, wantState: "open"},
+			},
+		},
+		{
+			name: "sukses terlambat saat open diabaikan",
+			steps: []breakerStep{
+				{op: "record", err: errUpstream},
+				{op: "record", err: errUpstream, wantState: "open"},
+				{op: "record", wantState: "open"},
+			},
+		},
+		{
+			name: "sukses terlambat saat half-open tidak menutup circuit",
+			steps: []breakerStep{
+				{op: "record", err: errUpstream},
+				{op: "record", err: errUpstream, wantState: "open"},
+				{op: "allow", probe: true, wantState: "half-open"},
+				{op: "record", wantState: "half-open"},
+				{op: "record", err: errUpstream, wantState: "half-open"},
+				{op: "record", probe: true, wantState: "closed"},
+			},
+		},
+		{
+			name: "percobaan batal langsung digantikan percobaan baru",
+			steps: []breakerStep{
+				{op: "record", err: errUpstream},
+				{op: "record", err: errUpstream, wantState: "open"},
+				{op: "allow", probe: true, wantState: "half-open"},
+				{op: "release", wantState: "half-open"},
+				{op: "release", probe: true, wantState: "open"},
+				{op: "allow", probe: true, wantState: "half-open"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewCircuitBreaker(BreakerSettings{
+				FailureThreshold: 2,
+				OpenDuration:     10 * time.Millisecond,
+				Budget:           time.Minute,
+			}, log.New(io.Discard, "", 0))
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			for i, s := range tt.steps {
+				switch s.op {
+				case "allow":
+					probe, err := b.Allow(ctx)
+					if err != nil {
+						t.Fatalf("langkah %d: Allow() error = %v", i, err)
+					}
+					if probe != s.probe {
+						t.Fatalf("langkah %d: Allow() probe = %v, want %v", i, probe, s.probe)
+					}
+				case "record":
+					b.Record(s.probe, s.err)
+				case "release":
+					b.Release(s.probe)
+				}
+				if s.wantState != "" && b.State() != s.wantState {
+					t.Fatalf("langkah %d (%s): State() = %s, want %s", i, s.op, b.State(), s.wantState)
+				}
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerBudget(t *testing.T) {
+	b := NewCircuitBreaker(BreakerSettings{
+		FailureThreshold: 1,
+		OpenDuration:     time.Hour,
+		Budget:           20 * time.Millisecond,
+	}, log.New(io.Discard, "", 0))
+	b.Record(false, errUpstream)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := b.Allow(ctx)
+	var unavailable *customErrors.ErrUpstreamUnavailable
+	if !errors.As(err, &unavailable) {
+		t.Fatalf("Allow() error = %v, want ErrUpstreamUnavailable", err)
+	}
+	if !errors.Is(err, errUpstream) {
+		t.Errorf("error tidak membungkus kegagalan terakhir: %v", err)
+	}
+	if unavailable.Downtime < 20*time.Millisecond {
+		t.Errorf("Downtime = %s, want >= 20ms", unavailable.Downtime)
+	}
+	// Setelah habis, Allow langsung gagal.
+	if _, err := b.Allow(context.Background()); !errors.As(err, &unavailable) {
+		t.Errorf("Allow() kedua error = %v", err)
+	}
+}
+
+func TestCircuitBreakerAllowContext(t *testing.T) {
+	b := NewCircuitBreaker(BreakerSettings{
+		FailureThreshold: 1,
+		OpenDuration:     time.Hour,
+		Budget:           2 * time.Hour,
+	}, log.New(io.Discard, "", 0))
+	b.Record(false, errUpstream)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := b.Allow(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Allow() error = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,270 @@
+package fetcher
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cacheEntry adalah satu response yang disimpan di disk.
+type cacheEntry struct {
+	URL        string      `json:"url"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	StoredAt   time.Time   `json:"stored_at"`
+}
+
+// cachingTransport adalah http.RoundTripper yang menyimpan response halaman
+// data di disk. Selama masih dalam TTL, response diambil dari cache tanpa
+// request ke API. Setelah TTL lewat, entri yang punya ETag/Last-Modified
+// divalidasi ulang dengan If-None-Match/If-Modified-Since; 304 memperpanjang
+// entri lama. Request login tidak pernah di-cache.
+type cachingTransport struct {
+	next    http.RoundTripper
+	dir     string
+	ttl     time.Duration
+	log     *log.Logger
+	exclude map[string]bool
+}
+
+// CacheOption mengatur perilaku opsional caching transport.
+type CacheOption func(*cachingTransport)
+
+// WithCacheExclude menandai URL yang tidak boleh di-cache, misal URL login.
+func WithCacheExclude(urls ...string) CacheOption {
+	return func(t *cachingTransport) {
+		for _, u := range urls {
+			t.exclude[u] = true
+		}
+	}
+}
+
+// WithCacheLogger mengganti logger untuk log hit/miss (default log.Default()).
+func WithCacheLogger(l *log.Logger) CacheOption {
+	return func(t *cachingTransport) { t.log = l }
+}
+
+// NewCachingTransport membungkus next (http.DefaultTransport jika nil) dengan
+// cache di direktori dir. Kunci cache adalah method, URL dan body request;
+// header Authorization sengaja tidak ikut agar cache tetap berlaku setelah
+// login ulang, sehingga cache ini hanya untuk pengembangan.
+func NewCachingTransport(next http.RoundTripper, dir string, ttl time.Duration, opts ...CacheOption) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	t := &cachingTransport{next: next, dir: dir, ttl: ttl, log: log.Default(), exclude: make(map[string]bool)}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+func (t *cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.cacheable(req) {
+		return t.next.RoundTrip(req)
+	}
+
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		b, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = b
+	}
+	key := cacheKey(req.Method, req.URL.String(), body)
+
+	// RoundTripper tidak boleh mengubah request asli.
+	out := req.Clone(req.Context())
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	out.ContentLength = int64(len(body))
+
+	entry := t.lookup(req, key)
+	if entry != nil && t.fresh(entry) {
+		t.log.Printf("Cache HIT: %s", req.URL)
+		return entry.response(req), nil
+	}
+	if entry != nil {
+		if etag := entry.Header.Get("ETag"); etag != "" {
+			out.Header.Set("If-None-Match", etag)
+		}
+		if lm := entry.Header.Get("Last-Modified"); lm != "" {
+			out.Header.Set("If-Modified-Since", lm)
+		}
+	}
+
+	resp, err := t.next.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		resp.Body.Close()
+		t.log.Printf("Cache REVALIDATED (304): %s", req.URL)
+		entry.StoredAt = time.Now()
+		t.store(key, entry)
+		return entry.response(req), nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	t.log.Printf("Cache MISS: %s", req.URL)
+	t.store(key, &cacheEntry{
+		URL:        req.URL.String(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       data,
+		StoredAt:   time.Now(),
+	})
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	return resp, nil
+}
+
+// Cached mengembalikan response dari cache jika entri untuk req masih dalam
+// TTL, tanpa request ke API. Fetcher memakainya sebelum mengambil token rate
+// limiter dan slot circuit breaker, sehingga halaman dari cache tidak ikut
+// membatasi atau memengaruhi request yang benar-benar ke API. Body req tidak
+// dikonsumsi; request tanpa GetBody tidak pernah dianggap hit.
+func (t *cachingTransport) Cached(req *http.Request) (*http.Response, bool) {
+	if !t.cacheable(req) {
+		return nil, false
+	}
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return nil, false
+		}
+		rc, err := req.GetBody()
+		if err != nil {
+			return nil, false
+		}
+		body, err = io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, false
+		}
+	}
+	entry := t.lookup(req, cacheKey(req.Method, req.URL.String(), body))
+	if entry == nil || !t.fresh(entry) {
+		return nil, false
+	}
+	t.log.Printf("Cache HIT: %s", req.URL)
+	return entry.response(req), true
+}
+
+// lookup memuat entri untuk key; entri rusak dicatat lalu diabaikan.
+func (t *cachingTransport) lookup(req *http.Request, key string) *cacheEntry {
+	entry, err := t.load(key)
+	if err != nil {
+		t.log.Printf("WARNING: cache HTTP rusak untuk %s, diabaikan: %v", req.URL, err)
+		return nil
+	}
+	return entry
+}
+
+func (t *cachingTransport) fresh(e *cacheEntry) bool {
+	return time.Since(e.StoredAt) < t.ttl
+}
+
+// cacheable: hanya request data (GET/POST) ke URL yang tidak dikecualikan.
+// Request dengan kredensial di body (login) harus dikecualikan lewat
+// WithCacheExclude.
+func (t *cachingTransport) cacheable(req *http.Request) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodPost {
+		return false
+	}
+	u := *req.URL
+	u.RawQuery = ""
+	return !t.exclude[req.URL.String()] && !t.exclude[u.String()]
+}
+
+func cacheKey(method, url string, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n", method, url)
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (t *cachingTransport) path(key string) string {
+	return filepath.Join(t.dir, key[:2], key+".json")
+}
+
+func (t *cachingTransport) load(key string) (*cacheEntry, error) {
+	data, err := os.ReadFile(t.path(key))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var e cacheEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// store menulis entri secara atomik (file sementara lalu rename). Kegagalan
+// hanya dicatat karena cache bersifat opsional.
+func (t *cachingTransport) store(key string, e *cacheEntry) {
+	if err := t.write(key, e); err != nil {
+		t.log.Printf("WARNING: gagal menulis cache HTTP untuk %s: %v", e.URL, err)
+	}
+}
+
+func (t *cachingTransport) write(key string, e *cacheEntry) error {
+	path := t.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+key+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // tidak berpengaruh setelah rename berhasil
+
+	w := bufio.NewWriter(tmp)
+	if err := json.NewEncoder(w).Encode(e); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (e *cacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode)),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
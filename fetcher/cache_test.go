@@ -0,0 +1,83 @@
+package fetcher
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCachingTransportCached(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer srv.Close()
+
+	newReq := func(body string) *http.Request {
+		req, err := http.NewRequest(http.MethodPost, srv.URL+"/data", bytes.NewReader([]byte(body)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+
+	tests := []struct {
+		name    string
+		ttl     time.Duration
+		warm    bool
+		body    string
+		wantHit bool
+	}{
+		{name: "kosong", ttl: time.Hour, body: "a"},
+		{name: "segar", ttl: time.Hour, warm: true, body: "a", wantHit: true},
+		{name: "body lain", ttl: time.Hour, warm: true, body: "b"},
+		{name: "kedaluwarsa", ttl: time.Nanosecond, warm: true, body: "a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := NewCachingTransport(nil, t.TempDir(), tt.ttl, WithCacheLogger(log.New(io.Discard, "", 0))).(*cachingTransport)
+			if tt.warm {
+				resp, err := tr.RoundTrip(newReq("a"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp.Body.Close()
+			}
+			before := hits.Load()
+
+			req := newReq(tt.body)
+			resp, ok := tr.Cached(req)
+			if ok != tt.wantHit {
+				t.Fatalf("Cached() hit = %v, want %v", ok, tt.wantHit)
+			}
+			if ok {
+				got, _ := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				if string(got) != `{"data":{}}` {
+					t.Errorf("body = %q", got)
+				}
+			}
+			if hits.Load() != before {
+				t.Error("Cached() tidak boleh request ke API")
+			}
+			// Body request harus tetap utuh untuk request berikutnya.
+			if got, _ := io.ReadAll(req.Body); string(got) != tt.body {
+				t.Errorf("body request = %q, want %q", got, tt.body)
+			}
+		})
+	}
+}
+
+func TestCachingTransportCachedExcluded(t *testing.T) {
+	tr := NewCachingTransport(nil, t.TempDir(), time.Hour, WithCacheExclude("http://api.test/login")).(*cachingTransport)
+	req, _ := http.NewRequest(http.MethodPost, "http://api.test/login", nil)
+	if _, ok := tr.Cached(req); ok {
+		t.Error("URL yang dikecualikan tidak boleh hit")
+	}
+}
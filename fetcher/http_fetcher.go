@@ -4,11 +4,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/aryadiwwt/synctodb/domain"
+	"github.com/aryadiwwt/synctodb/redact"
 )
 
 // Definisikan struct untuk menampung response dari API login
@@ -30,7 +33,14 @@ type dataRequestBody struct {
 	KdKab  string `json:"kd_kab"`
 }
 
+// RecordFetcher mengambil record mentah dari endpoint rekap mana pun yang
+// memakai login, request body dan pagination yang sama.
+type RecordFetcher interface {
+	FetchRecords(ctx context.Context, endpoint, kdProv, kdKab string) ([]json.RawMessage, error)
+}
+
 type Fetcher interface {
+	RecordFetcher
 	FetchOutputDetails(ctx context.Context, kdProv string, kdKab string) ([]domain.OutputDetail, error)
 }
 
@@ -43,22 +53,94 @@ type httpFetcher struct {
 	password  string
 	authToken string // Tempat menyimpan token setelah login berhasil
 	tahun     int
+	redactor  *redact.Redactor
+	limiter   *AdaptiveLimiter // nil berarti tanpa pembatasan laju
+	breaker   *CircuitBreaker  // nil berarti tanpa circuit breaker
+	// pageConcurrency adalah jumlah halaman yang diambil bersamaan saat
+	// respons memuat last_page; 1 berarti selalu berurutan.
+	pageConcurrency int
+	// pageTimeout membatasi satu halaman termasuk antrean rate limiter,
+	// circuit breaker dan percobaan ulang; 0 berarti tanpa batas sendiri.
+	// Batas per request HTTP diatur lewat http.Client.Timeout.
+	pageTimeout time.Duration
+	pagination  PaginationPolicy
+	request     RequestOptions
+}
+
+// Option mengatur perilaku opsional httpFetcher.
+type Option func(*httpFetcher)
+
+// WithRedactor memastikan password dan token tidak muncul di pesan error.
+// Token hasil login otomatis didaftarkan ke redactor ini.
+func WithRedactor(r *redact.Redactor) Option {
+	return func(f *httpFetcher) { f.redactor = r }
+}
+
+// WithRateLimiter melewatkan setiap request halaman lewat limiter. Response
+// 429/503 memperlambat limiter lalu halaman dicoba ulang.
+func WithRateLimiter(l *AdaptiveLimiter) Option {
+	return func(f *httpFetcher) { f.limiter = l }
+}
+
+// WithCircuitBreaker melewatkan login dan setiap request halaman lewat b.
+// Error koneksi dan status 5xx dihitung sebagai kegagalan.
+func WithCircuitBreaker(b *CircuitBreaker) Option {
+	return func(f *httpFetcher) { f.breaker = b }
+}
+
+// WithPageConcurrency mengambil sisa halaman secara paralel, maksimal n
+// sekaligus, jika halaman pertama memuat last_page.
+func WithPageConcurrency(n int) Option {
+	return func(f *httpFetcher) { f.pageConcurrency = n }
+}
+
+// WithPageTimeout membatasi waktu total untuk mendapatkan satu halaman.
+func WithPageTimeout(d time.Duration) Option {
+	return func(f *httpFetcher) { f.pageTimeout = d }
+}
+
+// WithPagination mengatur batas halaman dan penulisan ulang next_page_url.
+func WithPagination(p PaginationPolicy) Option {
+	return func(f *httpFetcher) { f.pagination = p }
 }
 
 // NewHTTPFetcher sekarang menerima konfigurasi login
-func NewHTTPFetcher(client *http.Client, dataURL, loginURL, username, password string, tahun int) Fetcher {
-	return &httpFetcher{
+func NewHTTPFetcher(client *http.Client, dataURL, loginURL, username, password string, tahun int, opts ...Option) Fetcher {
+	f := &httpFetcher{
 		client:   client,
 		dataURL:  dataURL,
 		loginURL: loginURL,
 		username: username,
 		password: password,
 		tahun:    tahun,
+
+		pageConcurrency: 1,
+		request:         RequestOptions{Style: StyleGetBody},
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	if f.redactor == nil {
+		f.redactor = redact.New()
 	}
+	f.redactor.Add(password)
+	return f
 }
 
-// Fungsi untuk memproses data
+// FetchOutputDetails mengambil output detail dari endpoint data utama.
 func (f *httpFetcher) FetchOutputDetails(ctx context.Context, kdProv string, kdKab string) ([]domain.OutputDetail, error) {
+	return Fetch[domain.OutputDetail](ctx, f, f.dataURL, kdProv, kdKab)
+}
+
+// FetchRecords mengambil semua halaman dari endpoint untuk satu wilayah dan
+// mengembalikan record mentahnya.
+func (f *httpFetcher) FetchRecords(ctx context.Context, endpoint, kdProv, kdKab string) ([]json.RawMessage, error) {
+	records, err := f.fetchRecords(ctx, endpoint, kdProv, kdKab)
+	// Semua error yang keluar dari fetcher disamarkan lebih dulu.
+	return records, f.redactor.Error(err)
+}
+
+func (f *httpFetcher) fetchRecords(ctx context.Context, endpoint, kdProv, kdKab string) ([]json.RawMessage, error) {
 	// 1. Proses autentikasi hanya dilakukan sekali di awal
 	if f.authToken == "" {
 		if err := f.authenticate(ctx); err != nil {
@@ -67,72 +149,171 @@ func (f *httpFetcher) FetchOutputDetails(ctx context.Context, kdProv string, kdK
 	}
 
 	// Slice untuk menampung hasil dari SEMUA halaman
-	var allData []domain.OutputDetail
+	var allData []json.RawMessage
 
-	// 2. Siapkan request body awal. Ini tidak akan berubah antar halaman.
-	dataPayload := dataRequestBody{
-		Tahun:  f.tahun,
-		KdProv: kdProv,
-		KdKab:  kdKab,
-	}
-	body, err := json.Marshal(dataPayload)
+	// 2. Siapkan parameter wilayah (body atau query). Ini tidak akan berubah
+	// antar halaman.
+	dr, err := f.newDataRequest(kdProv, kdKab)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal data request body: %w", err)
+		return nil, err
 	}
 
-	// 3. Mulai loop dari URL data utama
-	nextPageURL := f.dataURL
+	// 3. Ambil halaman pertama. Jika ada metadata last_page, sisa halaman
+	// diambil paralel berdasarkan nomor halaman.
+	// Setiap next_page_url divalidasi dulu karena bearer token ikut dikirim.
+	guard, err := newPageGuard(endpoint, f.pagination)
+	if err != nil {
+		return nil, err
+	}
+	first, err := f.fetchPage(ctx, endpoint, dr)
+	if err != nil {
+		return nil, err
+	}
+	allData = append(allData, first.Data...)
 
-	for nextPageURL != "" { // Lakukan loop selama masih ada halaman berikutnya
-		// Gunakan bytes.NewReader agar body bisa dibaca berulang kali di setiap loop
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, nextPageURL, bytes.NewReader(body))
+	if f.pageConcurrency > 1 && first.LastPage > 1 && first.CurrentPage <= 1 {
+		if err := guard.checkPages(first.LastPage); err != nil {
+			return nil, err
+		}
+		base := endpoint
+		if first.NextPageURL != nil {
+			if base, err = guard.next(*first.NextPageURL); err != nil {
+				return nil, err
+			}
+		}
+		rest, err := f.fetchPagesParallel(ctx, base, 2, first.LastPage, dr)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create request for page %s: %w", nextPageURL, err)
+			return nil, err
+		}
+		allData = append(allData, rest...)
+		if first.Total > 0 && len(allData) != first.Total {
+			log.Printf("WARNING: API melaporkan total %d record, tetapi %d yang diterima dari %d halaman.", first.Total, len(allData), first.LastPage)
+		}
+	} else {
+		// 4. Tanpa metadata: ikuti next_page_url satu per satu.
+		nextPageURL := ""
+		if first.NextPageURL != nil {
+			if nextPageURL, err = guard.next(*first.NextPageURL); err != nil {
+				return nil, err
+			}
 		}
 
-		req.Header.Set("Authorization", "Bearer "+f.authToken)
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Accept", "application/json")
+		for nextPageURL != "" { // Lakukan loop selama masih ada halaman berikutnya
+			page, err := f.fetchPage(ctx, nextPageURL, dr)
+			if err != nil {
+				return nil, err
+			}
 
-		log.Printf("Fetching data from: %s", nextPageURL)
+			// Tambahkan hasil dari halaman ini ke slice utama
+			allData = append(allData, page.Data...)
 
-		resp, err := f.client.Do(req)
-		if err != nil {
-			return nil, fmt.Errorf("failed to execute request for page %s: %w", nextPageURL, err)
+			// Perbarui URL untuk iterasi selanjutnya, atau hentikan loop
+			if page.NextPageURL != nil {
+				if nextPageURL, err = guard.next(*page.NextPageURL); err != nil {
+					return nil, err
+				}
+			} else {
+				nextPageURL = "" // Hentikan loop jika next_page_url adalah null
+			}
 		}
-		defer resp.Body.Close()
+	}
 
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("unexpected status code %d on page %s", resp.StatusCode, nextPageURL)
-		}
+	log.Printf("Total %d records fetched from all pages.", len(allData))
+	return allData, nil
+}
+
+// paginatedData adalah isi "data" pada respons API. Record dibiarkan mentah
+// dan di-decode terpisah oleh Fetch agar satu record yang rusak tidak
+// menggagalkan satu halaman penuh.
+type paginatedData struct {
+	Data        []json.RawMessage `json:"data"`          // Array data yang kita inginkan
+	NextPageURL *string           `json:"next_page_url"` // Pointer agar bisa null
 
-		// Definisikan struct yang cocok dengan respons API yang kompleks
-		type PaginatedData struct {
-			Data        []domain.OutputDetail `json:"data"`          // Array data yang kita inginkan
-			NextPageURL *string               `json:"next_page_url"` // Pointer agar bisa null
+	// Metadata pagination gaya Laravel; nol jika API tidak mengirimnya.
+	CurrentPage int `json:"current_page"`
+	LastPage    int `json:"last_page"`
+	Total       int `json:"total"`
+}
+
+type apiResponse struct {
+	Data paginatedData `json:"data"`
+}
+
+// maxOverloadRetries adalah jumlah percobaan ulang satu halaman yang dibalas
+// 429/503 saat rate limiter aktif.
+const maxOverloadRetries = 3
+
+// fetchPage mengambil dan men-decode satu halaman.
+func (f *httpFetcher) fetchPage(ctx context.Context, pageURL string, dr dataRequest) (*paginatedData, error) {
+	parent := ctx
+	if f.pageTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.pageTimeout)
+		defer cancel()
+	}
+	page, err := f.fetchPageAttempts(ctx, pageURL, dr)
+	// Bedakan batas waktu halaman dari batas waktu wilayah/run di atasnya.
+	if err != nil && parent.Err() == nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("page %s exceeded page timeout %s: %w", pageURL, f.pageTimeout, err)
+	}
+	return page, err
+}
+
+func (f *httpFetcher) fetchPageAttempts(ctx context.Context, pageURL string, dr dataRequest) (*paginatedData, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := f.newPageRequest(ctx, pageURL, dr)
+		if err != nil {
+			return nil, err
 		}
-		type ApiResponse struct {
-			Data PaginatedData `json:"data"`
+
+		// Halaman dari cache tidak memakai token limiter maupun slot breaker.
+		if resp, ok := f.cached(req); ok {
+			return decodePage(resp, pageURL)
 		}
 
-		var fullResponse ApiResponse
-		if err := json.NewDecoder(resp.Body).Decode(&fullResponse); err != nil {
-			return nil, fmt.Errorf("failed to decode api response for page %s: %w", nextPageURL, err)
+		if f.limiter != nil {
+			if err := f.limiter.Wait(ctx); err != nil {
+				return nil, fmt.Errorf("waiting for rate limiter for page %s: %w", pageURL, err)
+			}
 		}
 
-		// Tambahkan hasil dari halaman ini ke slice utama
-		allData = append(allData, fullResponse.Data.Data...)
+		log.Printf("Fetching data from: %s", pageURL)
+
+		probe, err := f.allow(ctx)
+		if err != nil {
+			return nil, err
+		}
+		start := time.Now()
+		resp, err := f.client.Do(req)
+		if err != nil {
+			f.record(ctx, probe, err)
+			return nil, fmt.Errorf("failed to execute request for page %s: %w", pageURL, err)
+		}
+		f.record(ctx, probe, breakerFailure(resp.StatusCode))
+		if f.limiter != nil {
+			f.limiter.Observe(resp.StatusCode, time.Since(start), retryAfter(resp.Header))
+		}
 
-		// Perbarui URL untuk iterasi selanjutnya, atau hentikan loop
-		if fullResponse.Data.NextPageURL != nil {
-			nextPageURL = *fullResponse.Data.NextPageURL
-		} else {
-			nextPageURL = "" // Hentikan loop jika next_page_url adalah null
+		overloaded := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable
+		if overloaded && f.limiter != nil && attempt < maxOverloadRetries {
+			resp.Body.Close()
+			continue
 		}
+		return decodePage(resp, pageURL)
 	}
+}
 
-	log.Printf("Total %d records fetched from all pages.", len(allData))
-	return allData, nil
+// decodePage membaca body halaman data dan selalu menutupnya.
+func decodePage(resp *http.Response, pageURL string) (*paginatedData, error) {
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d on page %s", resp.StatusCode, pageURL)
+	}
+	var fullResponse apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&fullResponse); err != nil {
+		return nil, fmt.Errorf("failed to decode api response for page %s: %w", pageURL, err)
+	}
+	return &fullResponse.Data, nil
 }
 
 // authenticate adalah fungsi internal untuk login dan menyimpan token.
@@ -153,11 +334,17 @@ func (f *httpFetcher) authenticate(ctx context.Context) error {
 	}
 	req.Header.Set("Content-Type", "application/json")
 
+	probe, err := f.allow(ctx)
+	if err != nil {
+		return err
+	}
 	resp, err := f.client.Do(req)
 	if err != nil {
+		f.record(ctx, probe, err)
 		return fmt.Errorf("failed to execute login request: %w", err)
 	}
 	defer resp.Body.Close()
+	f.record(ctx, probe, breakerFailure(resp.StatusCode))
 
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("login failed with status code: %d", resp.StatusCode)
@@ -172,8 +359,45 @@ func (f *httpFetcher) authenticate(ctx context.Context) error {
 		return fmt.Errorf("login successful but token is empty")
 	}
 
-	// Simpan token untuk request selanjutnya
+	// Simpan token untuk request selanjutnya, dan daftarkan ke redactor
+	// agar token tidak pernah ikut tercetak.
 	f.authToken = lr.Token
-	fmt.Println("Successfully authenticated and obtained token.") // Ganti dengan logger
+	f.redactor.Add(lr.Token)
+	log.Println("Successfully authenticated and obtained token.")
 	return nil
 }
+
+// allow menunggu izin circuit breaker, jika ada. probe diteruskan ke record.
+func (f *httpFetcher) allow(ctx context.Context) (probe bool, err error) {
+	if f.breaker == nil {
+		return false, nil
+	}
+	return f.breaker.Allow(ctx)
+}
+
+// responseCache diimplementasikan oleh transport dari NewCachingTransport.
+type responseCache interface {
+	Cached(req *http.Request) (*http.Response, bool)
+}
+
+// cached mengambil response halaman dari cache transport, jika ada.
+func (f *httpFetcher) cached(req *http.Request) (*http.Response, bool) {
+	c, ok := f.client.Transport.(responseCache)
+	if !ok {
+		return nil, false
+	}
+	return c.Cached(req)
+}
+
+// record melaporkan hasil request ke circuit breaker. Request yang batal
+// karena ctx tidak dihitung sebagai kegagalan API.
+func (f *httpFetcher) record(ctx context.Context, probe bool, err error) {
+	if f.breaker == nil {
+		return
+	}
+	if ctx.Err() != nil {
+		f.breaker.Release(probe)
+		return
+	}
+	f.breaker.Record(probe, err)
+}
@@ -0,0 +1,86 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+)
+
+// pageURL mengganti parameter page pada base dengan nomor halaman n.
+func pageURL(base string, n int) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("invalid page url %s: %w", base, err)
+	}
+	q := u.Query()
+	q.Set("page", strconv.Itoa(n))
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// fetchPagesParallel mengambil halaman from..last dengan paling banyak
+// pageConcurrency request bersamaan dan mengembalikan record-nya sesuai
+// urutan halaman. Error pertama membatalkan halaman lain yang belum selesai.
+func (f *httpFetcher) fetchPagesParallel(ctx context.Context, base string, from, last int, dr dataRequest) ([]json.RawMessage, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pages := make([][]json.RawMessage, last-from+1)
+	sem := make(chan struct{}, f.pageConcurrency)
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	for n := from; n <= last; n++ {
+		u, err := pageURL(base, n)
+		if err != nil {
+			fail(err)
+			break
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, u string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			page, err := f.fetchPage(ctx, u, dr)
+			if err != nil {
+				fail(err)
+				return
+			}
+			pages[i] = page.Data
+		}(n-from, u)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var all []json.RawMessage
+	for _, p := range pages {
+		all = append(all, p...)
+	}
+	return all, nil
+}
@@ -0,0 +1,87 @@
+package fetcher
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// PaginationPolicy membatasi URL halaman berikutnya yang boleh diikuti.
+// Halaman berikutnya selalu harus berada di origin (scheme + host + port)
+// endpoint yang dikonfigurasi, karena bearer token ikut dikirim ke sana.
+type PaginationPolicy struct {
+	// MaxPages adalah jumlah halaman maksimal per wilayah; 0 berarti tanpa
+	// batas.
+	MaxPages int
+	// UpgradeHTTPS mengubah next_page_url http:// menjadi https:// sebelum
+	// dicek, untuk API di belakang proxy TLS yang melaporkan skema http.
+	UpgradeHTTPS bool
+	// HostRewrites mengganti host next_page_url (misal host internal yang
+	// dilaporkan proxy) dengan host lain sebelum dicek. Key dan value berupa
+	// host[:port].
+	HostRewrites map[string]string
+}
+
+// pageGuard memvalidasi rangkaian next_page_url dalam satu pengambilan
+// wilayah: origin, pengulangan URL dan jumlah halaman.
+type pageGuard struct {
+	policy PaginationPolicy
+	origin *url.URL
+	seen   map[string]bool
+	pages  int
+}
+
+func newPageGuard(endpoint string, p PaginationPolicy) (*pageGuard, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint %s: %w", endpoint, err)
+	}
+	return &pageGuard{
+		policy: p,
+		origin: u,
+		seen:   map[string]bool{u.String(): true},
+		pages:  1,
+	}, nil
+}
+
+// next mengembalikan URL halaman berikutnya yang sudah ditulis ulang dan
+// divalidasi, atau error jika URL keluar dari origin, pernah dikunjungi,
+// atau melewati MaxPages.
+func (g *pageGuard) next(raw string) (string, error) {
+	ref, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid next_page_url %s: %w", raw, err)
+	}
+	u := g.origin.ResolveReference(ref)
+
+	if to, ok := g.policy.HostRewrites[u.Host]; ok {
+		u.Host = to
+	}
+	if g.policy.UpgradeHTTPS && u.Scheme == "http" {
+		u.Scheme = "https"
+	}
+
+	if !strings.EqualFold(u.Scheme, g.origin.Scheme) || !strings.EqualFold(u.Host, g.origin.Host) {
+		return "", fmt.Errorf("next_page_url %s leaves API origin %s://%s; refusing to send credentials", raw, g.origin.Scheme, g.origin.Host)
+	}
+
+	next := u.String()
+	if g.seen[next] {
+		return "", fmt.Errorf("pagination loop detected: %s was already fetched", next)
+	}
+	g.seen[next] = true
+
+	g.pages++
+	if err := g.checkPages(g.pages); err != nil {
+		return "", err
+	}
+	return next, nil
+}
+
+// checkPages memastikan n halaman masih dalam MaxPages.
+func (g *pageGuard) checkPages(n int) error {
+	if g.policy.MaxPages > 0 && n > g.policy.MaxPages {
+		return fmt.Errorf("pagination exceeds max pages %d", g.policy.MaxPages)
+	}
+	return nil
+}
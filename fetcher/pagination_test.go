@@ -0,0 +1,146 @@
+package fetcher
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPageGuardNext(t *testing.T) {
+	const endpoint = "https://api.test/v1/data?page=1"
+	tests := []struct {
+		name    string
+		policy  PaginationPolicy
+		urls    []string // next_page_url berturut-turut
+		want    string   // hasil URL terakhir
+		wantErr string
+	}{
+		{
+			name: "origin sama",
+			urls: []string{"https://api.test/v1/data?page=2"},
+			want: "https://api.test/v1/data?page=2",
+		},
+		{
+			name: "URL relatif",
+			urls: []string{"/v1/data?page=2"},
+			want: "https://api.test/v1/data?page=2",
+		},
+		{
+			name: "host beda huruf besar tetap sama",
+			urls: []string{"https://API.test/v1/data?page=2"},
+			want: "https://API.test/v1/data?page=2",
+		},
+		{
+			name:    "host lain ditolak",
+			urls:    []string{"https://evil.test/v1/data?page=2"},
+			wantErr: "leaves API origin",
+		},
+		{
+			name:    "port lain ditolak",
+			urls:    []string{"https://api.test:8443/v1/data?page=2"},
+			wantErr: "leaves API origin",
+		},
+		{
+			name:    "downgrade http ditolak",
+			urls:    []string{"http://api.test/v1/data?page=2"},
+			wantErr: "leaves API origin",
+		},
+		{
+			name:   "UpgradeHTTPS",
+			policy: PaginationPolicy{UpgradeHTTPS: true},
+			urls:   []string{"http://api.test/v1/data?page=2"},
+			want:   "https://api.test/v1/data?page=2",
+		},
+		{
+			name:   "HostRewrites",
+			policy: PaginationPolicy{HostRewrites: map[string]string{"10.0.0.5:8080": "api.test"}},
+			urls:   []string{"https://10.0.0.5:8080/v1/data?page=2"},
+			want:   "https://api.test/v1/data?page=2",
+		},
+		{
+			name:   "HostRewrites dan UpgradeHTTPS",
+			policy: PaginationPolicy{UpgradeHTTPS: true, HostRewrites: map[string]string{"internal:80": "api.test"}},
+			urls:   []string{"http://internal:80/v1/data?page=2"},
+			want:   "https://api.test/v1/data?page=2",
+		},
+		{
+			name:    "HostRewrites ke host lain tetap ditolak",
+			policy:  PaginationPolicy{HostRewrites: map[string]string{"api.test": "evil.test"}},
+			urls:    []string{"https://api.test/v1/data?page=2"},
+			wantErr: "leaves API origin",
+		},
+		{
+			name:    "kembali ke endpoint awal",
+			urls:    []string{endpoint},
+			wantErr: "pagination loop",
+		},
+		{
+			name:    "loop antar halaman",
+			urls:    []string{"https://api.test/v1/data?page=2", "https://api.test/v1/data?page=3", "/v1/data?page=2"},
+			wantErr: "pagination loop",
+		},
+		{
+			name:   "dalam MaxPages",
+			policy: PaginationPolicy{MaxPages: 3},
+			urls:   []string{"https://api.test/v1/data?page=2", "https://api.test/v1/data?page=3"},
+			want:   "https://api.test/v1/data?page=3",
+		},
+		{
+			name:    "melewati MaxPages",
+			policy:  PaginationPolicy{MaxPages: 2},
+			urls:    []string{"https://api.test/v1/data?page=2", "https://api.test/v1/data?page=3"},
+			wantErr: "exceeds max pages 2",
+		},
+		{
+			name:    "URL tidak valid",
+			urls:    []string{"https://api.test/%zz"},
+			wantErr: "invalid next_page_url",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := newPageGuard(endpoint, tt.policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got string
+			for _, u := range tt.urls {
+				if got, err = g.next(u); err != nil {
+					break
+				}
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("next = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageGuardCheckPages(t *testing.T) {
+	tests := []struct {
+		maxPages int
+		n        int
+		wantErr  bool
+	}{
+		{maxPages: 0, n: 100000},
+		{maxPages: 5, n: 5},
+		{maxPages: 5, n: 6, wantErr: true},
+	}
+	for _, tt := range tests {
+		g, err := newPageGuard("https://api.test/data", PaginationPolicy{MaxPages: tt.maxPages})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := g.checkPages(tt.n); (err != nil) != tt.wantErr {
+			t.Errorf("checkPages(%d) dengan MaxPages %d error = %v, wantErr %v", tt.n, tt.maxPages, err, tt.wantErr)
+		}
+	}
+}
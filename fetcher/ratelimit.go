@@ -0,0 +1,172 @@
+package fetcher
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit adalah batas laju request halaman untuk satu kredensial.
+// Laju dalam request per detik.
+type RateLimit struct {
+	Rate          float64       // laju awal
+	MinRate       float64       // batas bawah saat backoff
+	MaxRate       float64       // batas atas saat menaikkan laju
+	Burst         int           // kapasitas bucket
+	Increase      float64       // kenaikan aditif setiap response sehat
+	TargetLatency time.Duration // latency di atas ini dianggap API mulai berat; 0 berarti diabaikan
+}
+
+const (
+	// overloadDecrease memotong laju saat API membalas 429/503.
+	overloadDecrease = 0.5
+	// latencyDecrease memotong laju lebih lembut saat latency naik.
+	latencyDecrease = 0.75
+)
+
+// AdaptiveLimiter adalah token bucket dengan laju AIMD: naik sedikit demi
+// sedikit selama API sehat, turun setengah saat 429/503 dan turun sedikit
+// saat latency melewati target. Aman dipakai bersama oleh beberapa goroutine.
+type AdaptiveLimiter struct {
+	mu          sync.Mutex
+	name        string
+	limits      RateLimit
+	rate        float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+	log         *log.Logger
+}
+
+var (
+	limitersMu sync.Mutex
+	limiters   = make(map[string]*AdaptiveLimiter)
+)
+
+// LimiterFor mengembalikan limiter bersama untuk credential. Pemanggilan
+// berikutnya dengan credential yang sama memakai limiter yang sudah ada
+// (limits diabaikan), sehingga semua fetcher dengan kredensial yang sama
+// berbagi satu bucket.
+func LimiterFor(credential string, limits RateLimit, l *log.Logger) *AdaptiveLimiter {
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+	if lim, ok := limiters[credential]; ok {
+		return lim
+	}
+	lim := NewAdaptiveLimiter(credential, limits, l)
+	limiters[credential] = lim
+	return lim
+}
+
+// NewAdaptiveLimiter membuat limiter baru dengan bucket penuh.
+func NewAdaptiveLimiter(name string, limits RateLimit, l *log.Logger) *AdaptiveLimiter {
+	if limits.Burst < 1 {
+		limits.Burst = 1
+	}
+	if l == nil {
+		l = log.Default()
+	}
+	return &AdaptiveLimiter{
+		name:   name,
+		limits: limits,
+		rate:   limits.Rate,
+		tokens: float64(limits.Burst),
+		last:   time.Now(),
+		log:    l,
+	}
+}
+
+// Wait menunggu sampai satu token tersedia atau ctx selesai.
+func (l *AdaptiveLimiter) Wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		l.refill(now)
+		var wait time.Duration
+		switch {
+		case now.Before(l.pausedUntil):
+			wait = l.pausedUntil.Sub(now)
+		case l.tokens >= 1:
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		default:
+			wait = time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		}
+		l.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (l *AdaptiveLimiter) refill(now time.Time) {
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if max := float64(l.limits.Burst); l.tokens > max {
+		l.tokens = max
+	}
+	l.last = now
+}
+
+// Observe menyesuaikan laju dari hasil satu request. retryAfter (dari header
+// Retry-After) menahan semua request sampai waktunya lewat.
+func (l *AdaptiveLimiter) Observe(status int, latency, retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	old := l.rate
+	switch {
+	case status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable:
+		l.setRate(l.rate * overloadDecrease)
+		l.tokens = 0
+		if retryAfter > 0 {
+			l.pausedUntil = time.Now().Add(retryAfter)
+		}
+		l.log.Printf("Rate limit [%s]: API membalas %d, laju turun %.2f -> %.2f req/s (jeda %s)", l.name, status, old, l.rate, retryAfter)
+	case status >= 200 && status < 300 && l.limits.TargetLatency > 0 && latency > l.limits.TargetLatency:
+		l.setRate(l.rate * latencyDecrease)
+		l.log.Printf("Rate limit [%s]: latency %s di atas target %s, laju turun %.2f -> %.2f req/s", l.name, latency.Round(time.Millisecond), l.limits.TargetLatency, old, l.rate)
+	case status >= 200 && status < 300:
+		l.setRate(l.rate + l.limits.Increase)
+	}
+}
+
+func (l *AdaptiveLimiter) setRate(r float64) {
+	if r < l.limits.MinRate {
+		r = l.limits.MinRate
+	}
+	if r > l.limits.MaxRate {
+		r = l.limits.MaxRate
+	}
+	l.rate = r
+}
+
+// Rate mengembalikan laju saat ini dalam request per detik.
+func (l *AdaptiveLimiter) Rate() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rate
+}
+
+// retryAfter membaca header Retry-After dalam detik atau tanggal HTTP.
+func retryAfter(h http.Header) time.Duration {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
@@ -0,0 +1,151 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// observation adalah satu hasil request untuk Observe.
+type observation struct {
+	status  int
+	latency time.Duration
+}
+
+func TestAdaptiveLimiterObserve(t *testing.T) {
+	limits := RateLimit{
+		Rate:          4,
+		MinRate:       1,
+		MaxRate:       6,
+		Burst:         2,
+		Increase:      1,
+		TargetLatency: time.Second,
+	}
+	tests := []struct {
+		name string
+		obs  []observation
+		want float64
+	}{
+		{name: "sukses menaikkan aditif", obs: []observation{{200, 0}}, want: 5},
+		{name: "kenaikan dibatasi MaxRate", obs: []observation{{200, 0}, {200, 0}, {204, 0}}, want: 6},
+		{name: "429 memotong setengah", obs: []observation{{http.StatusTooManyRequests, 0}}, want: 2},
+		{name: "503 memotong setengah", obs: []observation{{http.StatusServiceUnavailable, 0}}, want: 2},
+		{name: "penurunan dibatasi MinRate", obs: []observation{{429, 0}, {429, 0}, {429, 0}}, want: 1},
+		{name: "latency tinggi turun lembut", obs: []observation{{200, 2 * time.Second}}, want: 3},
+		{name: "latency tepat target tetap naik", obs: []observation{{200, time.Second}}, want: 5},
+		{name: "status lain tidak mengubah laju", obs: []observation{{404, 0}, {500, 0}}, want: 4},
+		{name: "pulih setelah backoff", obs: []observation{{429, 0}, {200, 0}, {200, 0}}, want: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewAdaptiveLimiter("test", limits, log.New(io.Discard, "", 0))
+			for _, o := range tt.obs {
+				l.Observe(o.status, o.latency, 0)
+			}
+			if got := l.Rate(); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Rate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdaptiveLimiterWait(t *testing.T) {
+	tests := []struct {
+		name       string
+		rate       float64
+		burst      int
+		retryAfter time.Duration
+		overload   bool
+		immediate  int // jumlah Wait yang harus langsung berhasil
+	}{
+		{name: "burst penuh saat mulai", rate: 0.001, burst: 3, immediate: 3},
+		{name: "burst minimal satu", rate: 0.001, burst: 0, immediate: 1},
+		{name: "overload mengosongkan bucket", rate: 0.001, burst: 3, overload: true, immediate: 0},
+		{name: "Retry-After menahan request", rate: 1000, burst: 3, overload: true, retryAfter: time.Hour, immediate: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewAdaptiveLimiter("test", RateLimit{Rate: tt.rate, MinRate: tt.rate, MaxRate: tt.rate, Burst: tt.burst}, log.New(io.Discard, "", 0))
+			if tt.overload {
+				l.Observe(http.StatusTooManyRequests, 0, tt.retryAfter)
+			}
+			for i := 0; i < tt.immediate; i++ {
+				ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+				err := l.Wait(ctx)
+				cancel()
+				if err != nil {
+					t.Fatalf("Wait() ke-%d error = %v", i+1, err)
+				}
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Wait() setelah token habis error = %v, want context.DeadlineExceeded", err)
+			}
+		})
+	}
+}
+
+func TestAdaptiveLimiterRefill(t *testing.T) {
+	l := NewAdaptiveLimiter("test", RateLimit{Rate: 100, MinRate: 100, MaxRate: 100, Burst: 1}, log.New(io.Discard, "", 0))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(ctx); err != nil {
+			t.Fatalf("Wait() error = %v", err)
+		}
+	}
+	// Token pertama dari bucket, dua berikutnya masing-masing ~10ms.
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Errorf("tiga Wait() selesai dalam %s, want >= 15ms", elapsed)
+	}
+}
+
+func TestLimiterForSharesCredential(t *testing.T) {
+	quiet := log.New(io.Discard, "", 0)
+	a := LimiterFor("limiter-test-a", RateLimit{Rate: 1, MinRate: 1, MaxRate: 10}, quiet)
+	b := LimiterFor("limiter-test-a", RateLimit{Rate: 5, MinRate: 1, MaxRate: 10}, quiet)
+	c := LimiterFor("limiter-test-c", RateLimit{Rate: 5, MinRate: 1, MaxRate: 10}, quiet)
+	if a != b {
+		t.Error("kredensial sama harus berbagi limiter")
+	}
+	if a == c {
+		t.Error("kredensial berbeda tidak boleh berbagi limiter")
+	}
+	if b.Rate() != 1 {
+		t.Errorf("limits pemanggilan kedua harus diabaikan, Rate() = %v", b.Rate())
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		min   time.Duration
+		max   time.Duration
+	}{
+		{name: "kosong", value: "", min: 0, max: 0},
+		{name: "detik", value: "7", min: 7 * time.Second, max: 7 * time.Second},
+		{name: "nol", value: "0", min: 0, max: 0},
+		{name: "negatif", value: "-3", min: 0, max: 0},
+		{name: "tanggal HTTP", value: time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), min: 58 * time.Second, max: time.Minute},
+		{name: "tidak valid", value: "nanti", min: 0, max: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			if tt.value != "" {
+				h.Set("Retry-After", tt.value)
+			}
+			if got := retryAfter(h); got < tt.min || got > tt.max {
+				t.Errorf("retryAfter(%q) = %s, want [%s, %s]", tt.value, got, tt.min, tt.max)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package fetcher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// RequestStyle menentukan cara tahun, kd_prov dan kd_kab dikirim ke API.
+type RequestStyle string
+
+const (
+	// StyleGetBody mengirim body JSON pada request GET (perilaku bawaan API).
+	// Sebagian proxy membuang body ini.
+	StyleGetBody RequestStyle = "get_body"
+	// StylePost mengirim body JSON pada request POST.
+	StylePost RequestStyle = "post"
+	// StyleQuery mengirim parameter sebagai query string pada request GET.
+	StyleQuery RequestStyle = "query"
+)
+
+// RequestOptions mengatur bentuk request halaman data.
+type RequestOptions struct {
+	Style RequestStyle
+	// Headers ditambahkan ke setiap request data; tidak bisa menimpa
+	// Authorization.
+	Headers map[string]string
+	// Query ditambahkan ke query string setiap halaman.
+	Query map[string]string
+}
+
+// WithRequestOptions mengganti gaya request dan menambahkan header/query
+// statis ke setiap request halaman. Login tidak terpengaruh.
+func WithRequestOptions(o RequestOptions) Option {
+	return func(f *httpFetcher) { f.request = o }
+}
+
+// dataRequest adalah bagian request yang sama untuk semua halaman satu
+// wilayah.
+type dataRequest struct {
+	method string
+	body   []byte     // nil jika parameter dikirim lewat query
+	query  url.Values // ditimpakan ke query string setiap halaman
+}
+
+// newDataRequest menyiapkan parameter wilayah sesuai gaya request.
+func (f *httpFetcher) newDataRequest(kdProv, kdKab string) (dataRequest, error) {
+	dr := dataRequest{method: http.MethodGet, query: url.Values{}}
+	for k, v := range f.request.Query {
+		dr.query.Set(k, v)
+	}
+
+	if f.request.Style == StyleQuery {
+		dr.query.Set("tahun", strconv.Itoa(f.tahun))
+		dr.query.Set("kd_prov", kdProv)
+		dr.query.Set("kd_kab", kdKab)
+		return dr, nil
+	}
+
+	body, err := json.Marshal(dataRequestBody{
+		Tahun:  f.tahun,
+		KdProv: kdProv,
+		KdKab:  kdKab,
+	})
+	if err != nil {
+		return dr, fmt.Errorf("failed to marshal data request body: %w", err)
+	}
+	dr.body = body
+	if f.request.Style == StylePost {
+		dr.method = http.MethodPost
+	}
+	return dr, nil
+}
+
+// newPageRequest membuat request untuk satu halaman. Query dari dr
+// menimpa parameter yang sama pada pageURL (misal dari next_page_url).
+func (f *httpFetcher) newPageRequest(ctx context.Context, pageURL string, dr dataRequest) (*http.Request, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid page url %s: %w", pageURL, err)
+	}
+	if len(dr.query) > 0 {
+		q := u.Query()
+		for k, v := range dr.query {
+			q[k] = v
+		}
+		u.RawQuery = q.Encode()
+	}
+
+	// Gunakan bytes.NewReader agar body bisa dibaca berulang kali di setiap halaman
+	var body io.Reader
+	if dr.body != nil {
+		body = bytes.NewReader(dr.body)
+	}
+	req, err := http.NewRequestWithContext(ctx, dr.method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request for page %s: %w", pageURL, err)
+	}
+
+	for k, v := range f.request.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Authorization", "Bearer "+f.authToken)
+	if dr.body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/json")
+	}
+	return req, nil
+}
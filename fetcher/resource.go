@@ -0,0 +1,54 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+)
+
+// coercionReporter diimplementasikan oleh tipe domain yang mencatat field
+// angka yang perlu dikoersi saat decode (lihat domain.FlexNumber).
+type coercionReporter interface {
+	CoercionReport() (key string, notes []string)
+}
+
+// Fetch mengambil semua record dari endpoint untuk satu wilayah dan men-decode
+// masing-masing menjadi T.
+func Fetch[T any](ctx context.Context, f RecordFetcher, endpoint, kdProv, kdKab string) ([]T, error) {
+	raw, err := f.FetchRecords(ctx, endpoint, kdProv, kdKab)
+	if err != nil {
+		return nil, err
+	}
+	return decodeRecords[T](raw, endpoint), nil
+}
+
+// decodeRecords men-decode record satu per satu. Record yang gagal di-decode
+// dilewati, dan record yang field angkanya perlu dikoersi dicatat di log agar
+// bisa ditelusuri tanpa menghentikan proses.
+func decodeRecords[T any](records []json.RawMessage, endpoint string) []T {
+	out := make([]T, 0, len(records))
+	skipped, coerced := 0, 0
+
+	for i, raw := range records {
+		var rec T
+		if err := json.Unmarshal(raw, &rec); err != nil {
+			log.Printf("WARNING: record #%d from %s skipped: %v", i, endpoint, err)
+			skipped++
+			continue
+		}
+		if r, ok := any(rec).(coercionReporter); ok {
+			if key, notes := r.CoercionReport(); len(notes) > 0 {
+				coerced++
+				log.Printf("WARNING: record #%d (%s) from %s needed coercion: %s",
+					i, key, endpoint, strings.Join(notes, "; "))
+			}
+		}
+		out = append(out, rec)
+	}
+
+	if skipped > 0 || coerced > 0 {
+		log.Printf("%s: %d records decoded, %d coerced, %d skipped.", endpoint, len(out), coerced, skipped)
+	}
+	return out
+}
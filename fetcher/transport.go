@@ -0,0 +1,95 @@
+package fetcher
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// TransportSettings mengatur koneksi keluar ke API: proxy, TLS dan pool
+// koneksi. Satu transport dipakai bersama oleh login dan request data.
+type TransportSettings struct {
+	// ProxyURL dipakai untuk semua request kecuali host di NoProxy. Kosong
+	// berarti mengikuti HTTP_PROXY/HTTPS_PROXY/NO_PROXY dari environment.
+	ProxyURL string
+	// NoProxy memakai format NO_PROXY: daftar host, domain (.example.com),
+	// IP atau CIDR dipisah koma; "*" berarti tanpa proxy.
+	NoProxy string
+
+	// CAFile berisi sertifikat CA (PEM) yang dipercaya selain CA sistem.
+	CAFile string
+	// CertFile dan KeyFile adalah sertifikat klien (PEM) untuk mTLS.
+	CertFile string
+	KeyFile  string
+	// TLSMinVersion adalah versi TLS minimal, misal tls.VersionTLS12.
+	TLSMinVersion uint16
+
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int // 0 berarti tanpa batas
+	IdleConnTimeout     time.Duration
+	TLSHandshakeTimeout time.Duration
+}
+
+// NewTransport membuat http.Transport dari settings. File CA dan
+// sertifikat klien dibaca sekali di sini.
+func NewTransport(s TransportSettings) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+
+	if s.ProxyURL != "" {
+		if _, err := url.Parse(s.ProxyURL); err != nil {
+			return nil, fmt.Errorf("invalid proxy url: %w", err)
+		}
+		proxy := (&httpproxy.Config{
+			HTTPProxy:  s.ProxyURL,
+			HTTPSProxy: s.ProxyURL,
+			NoProxy:    s.NoProxy,
+		}).ProxyFunc()
+		t.Proxy = func(req *http.Request) (*url.URL, error) { return proxy(req.URL) }
+	}
+
+	tlsConfig := &tls.Config{MinVersion: s.TLSMinVersion}
+	if s.CAFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		pem, err := os.ReadFile(s.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA bundle: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA bundle %s contains no PEM certificates", s.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if s.CertFile != "" || s.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(s.CertFile, s.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	t.TLSClientConfig = tlsConfig
+
+	if s.MaxIdleConns > 0 {
+		t.MaxIdleConns = s.MaxIdleConns
+	}
+	if s.MaxIdleConnsPerHost > 0 {
+		t.MaxIdleConnsPerHost = s.MaxIdleConnsPerHost
+	}
+	t.MaxConnsPerHost = s.MaxConnsPerHost
+	if s.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = s.IdleConnTimeout
+	}
+	if s.TLSHandshakeTimeout > 0 {
+		t.TLSHandshakeTimeout = s.TLSHandshakeTimeout
+	}
+	return t, nil
+}
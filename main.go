@@ -2,17 +2,23 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
-	"time"
+	"sync/atomic"
+	"syscall"
 
 	"github.com/aryadiwwt/synctodb/config"
+	"github.com/aryadiwwt/synctodb/domain"
+	customErrors "github.com/aryadiwwt/synctodb/errors"
 	"github.com/aryadiwwt/synctodb/fetcher"
+	"github.com/aryadiwwt/synctodb/redact"
 	"github.com/aryadiwwt/synctodb/storer"
 	"github.com/aryadiwwt/synctodb/synchronizer"
 	"github.com/joho/godotenv"
@@ -21,99 +27,378 @@ import (
 	_ "github.com/lib/pq"
 )
 
+// exitUpstreamUnavailable dipakai jika circuit breaker menghentikan run
+// karena API tidak pulih dalam budget-nya.
+const exitUpstreamUnavailable = 4
+
+// exitInterrupted dipakai jika run dihentikan SIGINT/SIGTERM, sama dengan
+// konvensi shell untuk proses yang dihentikan Ctrl-C.
+const exitInterrupted = 130
+
 func main() {
 	if err := godotenv.Load(); err != nil {
 		log.Println("Warning: Could not load .env file")
 	}
-	logger := log.New(os.Stdout, "DATA-SYNC-SERVICE: ", log.LstdFlags|log.Lshortfile)
+	// Semua output log melewati redactor sehingga password, DSN dan bearer
+	// token tidak pernah tercetak, termasuk dari log bawaan package lain.
+	redactor := redact.New()
+	log.SetOutput(redactor.Writer(os.Stderr))
+	logger := log.New(redactor.Writer(os.Stdout), "DATA-SYNC-SERVICE: ", log.LstdFlags|log.Lshortfile)
 
-	// Load Configuration
-	cfg := config.New()
-	// Pastikan username dan password tidak kosong
-	if cfg.APIUsername == "" || cfg.APIPassword == "" {
-		logger.Fatal("FATAL: API_USERNAME and API_PASSWORD environment variables must be set.")
+	// Tanpa subcommand (atau dengan "sync") aplikasi menjalankan sinkronisasi
+	// seperti sebelumnya, sehingga `main -prov=51` tetap berfungsi.
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		switch args[0] {
+		case "sync":
+			args = args[1:]
+		case "config":
+			os.Exit(runConfigCommand(args[1:]))
+		case "verify":
+			os.Exit(runVerify(logger, redactor, args[1:]))
+		case "export":
+			os.Exit(runExport(logger, redactor, args[1:]))
+		default:
+			fmt.Fprintf(os.Stderr, "Perintah tidak dikenal: %s (gunakan sync, verify, export atau config)\n", args[0])
+			os.Exit(2)
+		}
 	}
 
-	// Definisikan flag untuk command line
-	// Akan membaca flag seperti: -prov="11,12,51"
-	provinsiPtr := flag.String("prov", "", "Daftar kode provinsi yang dipisahkan koma (contoh: 11,12,51)")
-	kabupatenPtr := flag.String("kab", "", "Kode kabupaten untuk memulai proses (opsional)")
-	flag.Parse() // Baca semua flag yang didefinisikan
-	// Setup Dependencies
-	// Koneksi DB
-	db, err := sqlx.Connect("postgres", cfg.DatabaseURL)
-	if err != nil {
-		logger.Fatalf("FATAL: Could not connect to database: %v", err)
-	}
-	defer db.Close()
-	// ---- KONFIGURASI POOL----
+	os.Exit(runSync(logger, redactor, args))
+}
 
-	// SetConnMaxLifetime: Durasi maksimum koneksi boleh dibuka.
-	// Mengaturnya lebih rendah dari timeout firewall (misal 5 menit) akan
-	// secara otomatis mendaur ulang koneksi sebelum diputus oleh firewall.
-	db.SetConnMaxLifetime(10 * time.Minute)
+// configFlags mendaftarkan flag yang dipakai bersama oleh semua perintah
+// untuk memuat konfigurasi.
+func configFlags(fs *flag.FlagSet) (path *string, overrides *config.Overrides) {
+	overrides = &config.Overrides{}
+	path = fs.String("config", "", "Path file konfigurasi YAML/TOML (default: env "+config.EnvConfigFile+")")
+	fs.Var(overrides, "set", "Menimpa satu nilai konfigurasi, contoh: -set sync.delay=10s (boleh diulang)")
+	return path, overrides
+}
 
-	// SetMaxIdleConns: Jumlah maksimum koneksi yang boleh idle di pool.
-	db.SetMaxIdleConns(10)
+// runSync menjalankan sinkronisasi dan mengembalikan exit code. Kegagalan
+// saat persiapan tetap langsung keluar lewat logger.Fatalf.
+func runSync(logger *log.Logger, redactor *redact.Redactor, args []string) int {
+	// Definisikan flag untuk command line
+	// Akan membaca flag seperti: -prov="11,12,51"
+	fs := flag.NewFlagSet("sync", flag.ExitOnError)
+	configPath, overrides := configFlags(fs)
+	provinsiPtr := fs.String("prov", "", "Daftar kode provinsi yang dipisahkan koma (contoh: 11,12,51)")
+	kabupatenPtr := fs.String("kab", "", "Kode kabupaten untuk memulai proses (opsional)")
+	fs.Parse(args) // Baca semua flag yang didefinisikan
 
-	// SetMaxOpenConns: Jumlah maksimum koneksi yang boleh dibuka ke database.
-	db.SetMaxOpenConns(100)
+	// Flag -prov dan -kab adalah lapisan paling akhir, setelah -set.
+	flagOverrides := *overrides
+	if *provinsiPtr != "" {
+		flagOverrides = append(flagOverrides, "sync.provinsi="+*provinsiPtr)
+	}
+	if *kabupatenPtr != "" {
+		flagOverrides = append(flagOverrides, "sync.start_kabupaten="+*kabupatenPtr)
+	}
 
-	// SetConnMaxIdleTime: Durasi maksimum koneksi boleh idle sebelum ditutup.
-	// Ini membantu membuang koneksi yang tidak terpakai.
-	db.SetConnMaxIdleTime(10 * time.Minute)
+	// Load Configuration
+	cfg := loadConfig(logger, redactor, *configPath, flagOverrides)
 
-	// ---------------------------------------------
-	// HTTP Client - dikonfigurasi sekali dan di-inject
-	httpClient := &http.Client{
-		Timeout: 120 * time.Minute,
+	// Setup Dependencies
+	// Database hanya dibuka jika dipakai, sehingga sink parquet dengan
+	// wilayah embedded/csv bisa berjalan tanpa Postgres.
+	var db *sqlx.DB
+	if needsDatabase(cfg) {
+		db = openDatabase(logger, redactor, cfg)
+		defer db.Close()
 	}
-	// Proses input dari flag
-	var daftarProvinsi []string
-	if *provinsiPtr != "" {
-		// Pisahkan string menjadi slice berdasarkan koma
-		daftarProvinsi = strings.Split(*provinsiPtr, ",")
+	// Proses daftar provinsi dari konfigurasi/flag
+	daftarProvinsi := cfg.Sync.Provinsi
+	if len(daftarProvinsi) > 0 {
 		logger.Printf("Akan memproses data untuk provinsi: %v", daftarProvinsi)
 	} else {
 		logger.Println("Tidak ada kode provinsi yang ditentukan. Untuk memproses semua, biarkan flag -prov kosong.")
 	}
-	// Ambil nilai dari flag kabupaten
-	startKabupaten := *kabupatenPtr
+	// Ambil nilai kabupaten awal
+	startKabupaten := cfg.Sync.StartKabupaten
 	if startKabupaten != "" {
-		// Ambil nilai mentah dari flag
-		kodeKabStr := strings.TrimSpace(*kabupatenPtr)
-		// Lakukan formatting yang sama seperti yang kita lakukan pada data lain
-		num, err := strconv.Atoi(kodeKabStr)
-		if err != nil {
-			logger.Fatalf("Error: Kode kabupaten '%s' bukan angka yang valid.", kodeKabStr)
-		}
-		// Format menjadi string 2 digit
-		startKabupaten = fmt.Sprintf("%02d", num)
+		// Lakukan formatting yang sama seperti yang kita lakukan pada data lain.
+		// Validasi konfigurasi sudah memastikan kodenya valid, sehingga
+		// "3", "03" dan "5103" sama-sama menjadi "03".
+		kode, _ := domain.ParseKodeWilayah(startKabupaten)
+		startKabupaten = kode.Segment()
 		logger.Printf("Proses akan dimulai dari kabupaten dengan kode yang diformat: %s", startKabupaten)
 	}
 	// Create Concrete Implementations
-	// Berikan semua konfigurasi yang dibutuhkan oleh Fetcher
-	dataFetcher := fetcher.NewHTTPFetcher(
-		httpClient,
-		cfg.APIURL,
-		cfg.APILoginURL,
-		cfg.APIUsername,
-		cfg.APIPassword,
-		cfg.APIDataTahun,
-	)
-	dataStorer := storer.NewDBStorer(db)
+	dataFetcher, err := newFetcher(cfg, redactor)
+	if err != nil {
+		logger.Fatalf("FATAL: %v", err)
+	}
+	storerOpts := []storer.Option{storer.WithRedactor(redactor)}
+	if cfg.Storer.Dimensions {
+		storerOpts = append(storerOpts, storer.WithDimensions())
+	}
+	if cfg.Storer.StarSchema {
+		storerOpts = append(storerOpts, storer.WithStarSchema())
+	}
+	if cfg.Storer.RowHash {
+		storerOpts = append(storerOpts, storer.WithRowHash())
+	}
+	var sinks []storer.Storer
+	for _, sink := range cfg.Storer.Sinks {
+		switch sink {
+		case config.SinkPostgres:
+			sinks = append(sinks, storer.NewDBStorer(db, storerOpts...))
+		case config.SinkParquet:
+			sinks = append(sinks, storer.NewParquetStorer(cfg.Storer.ParquetDir))
+		}
+	}
+	dataStorer := storer.NewMultiStorer(sinks...)
+	if m, ok := dataStorer.(storer.Migrator); ok && cfg.Database.AutoMigrate {
+		if err := m.Migrate(context.Background()); err != nil {
+			logger.Fatalf("FATAL: Migrasi skema gagal: %v", err)
+		}
+	}
+	// Kolom yang ditambahkan versi baru selalu diperiksa, juga tanpa
+	// auto_migrate, agar upsert tidak gagal di setiap kabupaten.
+	if u, ok := dataStorer.(storer.SchemaUpgrader); ok {
+		if err := u.UpgradeSchema(context.Background()); err != nil {
+			logger.Fatalf("FATAL: %v", err)
+		}
+	}
+	recorder := synchronizer.NewRunRecorder(cfg.Sync.StateFile, daftarProvinsi, logger)
+	engineOpts := []synchronizer.EngineOption{
+		synchronizer.WithWilayahTimeout(cfg.Sync.WilayahTimeout),
+		synchronizer.WithProgress(recorder.Completed),
+	}
+	tahun := strconv.Itoa(cfg.API.Tahun)
+	if cfg.Storer.Aggregates {
+		aggregates := storer.NewAggregateRefresher(db, redactor)
+		if m, ok := aggregates.(storer.Migrator); ok && cfg.Database.AutoMigrate {
+			if err := m.Migrate(context.Background()); err != nil {
+				logger.Fatalf("FATAL: Migrasi tabel agregat gagal: %v", err)
+			}
+		}
+		engineOpts = append(engineOpts, synchronizer.WithAfterStore(func(ctx context.Context, w storer.Wilayah) error {
+			kab, err := w.Kode()
+			if err != nil {
+				return fmt.Errorf("kode wilayah tidak valid: %w", err)
+			}
+			return aggregates.RefreshAggregates(ctx, tahun, kab)
+		}))
+	}
+	if cfg.Sync.SkipUnchanged {
+		hashes := storer.NewContentHashStore(db, redactor)
+		if m, ok := hashes.(storer.Migrator); ok && cfg.Database.AutoMigrate {
+			if err := m.Migrate(context.Background()); err != nil {
+				logger.Fatalf("FATAL: Migrasi tabel content hash gagal: %v", err)
+			}
+		}
+		engineOpts = append(engineOpts, synchronizer.WithContentHash(hashes, tahun))
+	}
+	wilayahSource, err := newWilayahSource(cfg.Wilayah, db, redactor)
+	if err != nil {
+		logger.Fatalf("FATAL: %v", err)
+	}
 
 	// Compose The Application
-	// Inject semua dependensi ke dalam synchronizer
-	postSync := synchronizer.NewOutputDetailSynchronizer(dataFetcher, dataStorer, logger)
+	// Setiap resource didaftarkan sebagai Engine. Resource baru cukup
+	// dideklarasikan dengan Table-nya lalu didaftarkan lewat
+	// synchronizer.NewTableEngine.
+	transforms, err := synchronizer.OutputDetailTransforms(cfg.Transform.Profile, cfg.Transform.Steps, cfg.Transform.Mappings, logger)
+	if err != nil {
+		logger.Fatalf("FATAL: %v", err)
+	}
+	registry := synchronizer.NewRegistry()
+	if err := registry.Register(synchronizer.NewOutputDetailSynchronizer(
+		synchronizer.OutputDetailResource(cfg.API.URL, transforms), dataFetcher, dataStorer, wilayahSource, logger, cfg.Sync.Delay, engineOpts...)); err != nil {
+		logger.Fatalf("FATAL: %v", err)
+	}
+
+	var runners []synchronizer.Runner
+	for _, name := range cfg.Sync.Resources {
+		run, ok := registry.Get(name)
+		if !ok {
+			logger.Fatalf("FATAL: resource %q tidak dikenal (tersedia: %s)", name, strings.Join(registry.Names(), ", "))
+		}
+		runners = append(runners, run)
+	}
 
 	// Run The Application
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	// SIGINT/SIGTERM membatalkan context run. Transaksi kabupaten yang sedang
+	// disimpan di-rollback oleh database/sql, sehingga tidak ada data setengah
+	// tersimpan. Sinyal kedua menghentikan proses seketika.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Sync.RunTimeout)
 	defer cancel()
+	var interrupted atomic.Bool
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case sig := <-sigs:
+			logger.Printf("Sinyal %s diterima, menghentikan sinkronisasi... (kirim sekali lagi untuk keluar paksa)", sig)
+			interrupted.Store(true)
+			signal.Stop(sigs)
+			cancel()
+		case <-done:
+		}
+	}()
 
-	if err := postSync.Synchronize(ctx, daftarProvinsi, startKabupaten); err != nil {
-		logger.Fatalf("Proses sinkronisasi gagal: %v", err)
+	for _, run := range runners {
+		recorder.Start(run.Name())
+		err := run.Synchronize(ctx, daftarProvinsi, startKabupaten)
+		if err == nil {
+			continue
+		}
+
+		var unavailable *customErrors.ErrUpstreamUnavailable
+		switch {
+		case interrupted.Load():
+			recorder.Finish(synchronizer.RunInterrupted, err)
+			if last := recorder.State().LastCompleted; last != nil {
+				logger.Printf("Sinkronisasi %s dihentikan. Kabupaten terakhir yang selesai: Prov %s Kab %s.", run.Name(), last.KodeProvinsi, last.KodeKabupaten)
+			} else {
+				logger.Printf("Sinkronisasi %s dihentikan sebelum ada kabupaten yang selesai.", run.Name())
+			}
+			return exitInterrupted
+		case errors.As(err, &unavailable):
+			recorder.Finish(synchronizer.RunFailed, err)
+			logger.Printf("FATAL: %v", err)
+			return exitUpstreamUnavailable
+		default:
+			recorder.Finish(synchronizer.RunFailed, err)
+			logger.Printf("Proses sinkronisasi %s gagal: %v", run.Name(), err)
+			return 1
+		}
 	}
 
+	recorder.Finish(synchronizer.RunCompleted, nil)
 	logger.Println("Application finished successfully.")
+	return 0
+}
+
+// loadConfig memuat konfigurasi dan mendaftarkan semua nilai rahasianya ke
+// redactor sebelum apa pun sempat tercetak.
+func loadConfig(logger *log.Logger, redactor *redact.Redactor, path string, overrides []string) *config.Config {
+	cfg, err := config.Load(path, overrides)
+	if cfg != nil {
+		redactor.Add(cfg.SecretValues()...)
+	}
+	if err != nil {
+		logger.Fatalf("FATAL: %v", err)
+	}
+	return cfg
+}
+
+// openDatabase membuka koneksi dengan pengaturan pool dari konfigurasi.
+func openDatabase(logger *log.Logger, redactor *redact.Redactor, cfg *config.Config) *sqlx.DB {
+	db, err := sqlx.Connect("postgres", cfg.Database.URL)
+	if err != nil {
+		logger.Fatalf("FATAL: Could not connect to database: %v", redactor.Error(err))
+	}
+	// ---- KONFIGURASI POOL (lihat config.DatabaseConfig) ----
+	db.SetConnMaxLifetime(cfg.Database.ConnMaxLifetime)
+	db.SetMaxIdleConns(cfg.Database.MaxIdleConns)
+	db.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+	db.SetConnMaxIdleTime(cfg.Database.ConnMaxIdleTime)
+	return db
+}
+
+// newFetcher membuat Fetcher dengan semua konfigurasi API dan HTTP.
+func newFetcher(cfg *config.Config, redactor *redact.Redactor) (fetcher.Fetcher, error) {
+	// Satu transport (proxy, TLS, pool koneksi) dipakai bersama oleh login
+	// dan request data. Versi TLS sudah divalidasi saat konfigurasi dimuat.
+	t := cfg.HTTP.Transport
+	tlsVersion, _ := t.TLSVersion()
+	transport, err := fetcher.NewTransport(fetcher.TransportSettings{
+		ProxyURL:            t.ProxyURL,
+		NoProxy:             t.NoProxy,
+		CAFile:              t.CAFile,
+		CertFile:            t.CertFile,
+		KeyFile:             t.KeyFile,
+		TLSMinVersion:       tlsVersion,
+		MaxIdleConns:        t.MaxIdleConns,
+		MaxIdleConnsPerHost: t.MaxIdleConnsPerHost,
+		MaxConnsPerHost:     t.MaxConnsPerHost,
+		IdleConnTimeout:     t.IdleConnTimeout,
+		TLSHandshakeTimeout: t.TLSHandshakeTimeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("http transport: %w", err)
+	}
+
+	// HTTP Client - dikonfigurasi sekali dan di-inject
+	httpClient := &http.Client{
+		Timeout:   cfg.HTTP.Timeout,
+		Transport: transport,
+	}
+	if cfg.HTTP.Cache.Enabled {
+		httpClient.Transport = fetcher.NewCachingTransport(transport, cfg.HTTP.Cache.Dir, cfg.HTTP.Cache.TTL,
+			fetcher.WithCacheExclude(cfg.API.LoginURL))
+	}
+	opts := []fetcher.Option{
+		fetcher.WithRedactor(redactor),
+		fetcher.WithPageConcurrency(cfg.HTTP.PageConcurrency),
+		fetcher.WithPageTimeout(cfg.HTTP.PageTimeout),
+		fetcher.WithRequestOptions(fetcher.RequestOptions{
+			Style:   fetcher.RequestStyle(cfg.API.RequestStyle),
+			Headers: cfg.API.Headers,
+			Query:   cfg.API.Query,
+		}),
+		fetcher.WithPagination(fetcher.PaginationPolicy{
+			MaxPages:     cfg.HTTP.Pagination.MaxPages,
+			UpgradeHTTPS: cfg.HTTP.Pagination.UpgradeHTTPS,
+			HostRewrites: cfg.HTTP.Pagination.HostRewrites,
+		}),
+	}
+	if cfg.HTTP.RateLimit.Enabled {
+		// Sudah divalidasi saat konfigurasi dimuat.
+		rl, _ := cfg.HTTP.RateLimit.For(cfg.API.Username)
+		opts = append(opts, fetcher.WithRateLimiter(fetcher.LimiterFor(cfg.API.Username, fetcher.RateLimit{
+			Rate:          rl.Rate,
+			MinRate:       rl.MinRate,
+			MaxRate:       rl.MaxRate,
+			Burst:         rl.Burst,
+			Increase:      rl.Increase,
+			TargetLatency: rl.TargetLatency,
+		}, nil)))
+	}
+	if cb := cfg.HTTP.CircuitBreaker; cb.Enabled {
+		opts = append(opts, fetcher.WithCircuitBreaker(fetcher.NewCircuitBreaker(fetcher.BreakerSettings{
+			FailureThreshold: cb.FailureThreshold,
+			OpenDuration:     cb.OpenDuration,
+			Budget:           cb.Budget,
+		}, nil)))
+	}
+	return fetcher.NewHTTPFetcher(
+		httpClient,
+		cfg.API.URL,
+		cfg.API.LoginURL,
+		cfg.API.Username,
+		cfg.API.Password,
+		cfg.API.Tahun,
+		opts...,
+	), nil
+}
+
+// needsDatabase melaporkan apakah sync memerlukan koneksi Postgres.
+func needsDatabase(cfg *config.Config) bool {
+	if cfg.Wilayah.Source == config.WilayahSourceTable {
+		return true
+	}
+	for _, sink := range cfg.Storer.Sinks {
+		if sink == config.SinkPostgres {
+			return true
+		}
+	}
+	return false
+}
+
+// newWilayahSource memilih sumber daftar kabupaten/kota sesuai konfigurasi.
+func newWilayahSource(cfg config.WilayahConfig, db *sqlx.DB, redactor *redact.Redactor) (storer.WilayahSource, error) {
+	switch cfg.Source {
+	case config.WilayahSourceEmbedded:
+		return storer.NewEmbeddedWilayahSource()
+	case config.WilayahSourceCSV:
+		return storer.NewCSVWilayahSource(cfg.CSVFile)
+	default:
+		return storer.NewTableWilayahSource(db, cfg.Table, cfg.ProvinsiColumn, cfg.KabupatenColumn, redactor)
+	}
 }
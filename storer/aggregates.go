@@ -0,0 +1,163 @@
+package storer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aryadiwwt/synctodb/domain"
+	"github.com/aryadiwwt/synctodb/redact"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// AggregateRefresher menghitung ulang tabel agregat untuk satu
+// kabupaten/kota setelah datanya disimpan.
+type AggregateRefresher interface {
+	RefreshAggregates(ctx context.Context, tahun string, kabupaten domain.KodeWilayah) error
+}
+
+// aggregateSchema membuat tabel agregat. Kolom kabupaten selalu berisi kode
+// lengkap bertitik dan menjadi batas refresh inkremental; kolom kode berisi
+// kode wilayah seperti yang tersimpan di siskeudes_detail_output agar bisa
+// di-join langsung. Dengan profil transformasi raw kode tersebut relatif
+// ("03", "03.2001.") dan berulang antar kabupaten, karena itu kabupaten ikut
+// menjadi primary key.
+//
+// Realisasi adalah jumlah realisasi0+realisasi1+realisasi2 (per tahap),
+// sedangkan fisik memakai capaian tertinggi dari fisik0-2 (kumulatif).
+var aggregateSchema = []string{
+	`CREATE TABLE IF NOT EXISTS agg_realisasi_wilayah (
+        tahun TEXT NOT NULL,
+        kabupaten TEXT NOT NULL,
+        tingkat TEXT NOT NULL,
+        kode TEXT NOT NULL,
+        nama TEXT,
+        jumlah_record BIGINT NOT NULL,
+        pagu NUMERIC(20,2) NOT NULL,
+        realisasi NUMERIC(20,2) NOT NULL,
+        sisa_anggaran NUMERIC(20,2) NOT NULL,
+        persen_realisasi NUMERIC(7,2),
+        persen_fisik NUMERIC(7,2),
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+        PRIMARY KEY (tahun, kabupaten, tingkat, kode)
+    )`,
+	`CREATE INDEX IF NOT EXISTS idx_agg_realisasi_wilayah_kabupaten ON agg_realisasi_wilayah (tahun, kabupaten)`,
+	`CREATE TABLE IF NOT EXISTS agg_sumber_dana (
+        tahun TEXT NOT NULL,
+        kabupaten TEXT NOT NULL,
+        tingkat TEXT NOT NULL,
+        kode TEXT NOT NULL,
+        kode_sumber TEXT NOT NULL,
+        jumlah_record BIGINT NOT NULL,
+        pagu NUMERIC(20,2) NOT NULL,
+        realisasi NUMERIC(20,2) NOT NULL,
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+        PRIMARY KEY (tahun, kabupaten, tingkat, kode, kode_sumber)
+    )`,
+	`CREATE INDEX IF NOT EXISTS idx_agg_sumber_dana_kabupaten ON agg_sumber_dana (tahun, kabupaten)`,
+}
+
+// aggregateLevels adalah tingkat wilayah yang diagregasi beserta kolom kode
+// dan namanya.
+var aggregateLevels = []struct {
+	tingkat, kode, nama string
+}{
+	{"desa", "kd_desa", "nama_desa"},
+	{"kecamatan", "kd_kec", "nama_kecamatan"},
+	{"kabupaten", "kd_kab", "nama_kabupaten"},
+}
+
+const (
+	aggregateRealisasi = `COALESCE(sum(COALESCE(realisasi0, 0) + COALESCE(realisasi1, 0) + COALESCE(realisasi2, 0)), 0)`
+
+	// aggregateSource memilih baris satu kabupaten dengan pencocokan kode
+	// yang sama seperti outputDetailTotalsQuery. $1 tahun, $2 kabupaten
+	// bertitik, $3 kd_prov, $4-$6 bentuk kode kabupaten.
+	aggregateSource = `FROM siskeudes_detail_output
+        WHERE tahun = $1 AND kd_prov = $3 AND COALESCE(kd_kab_raw, kd_kab) IN ($4, $5, $6)`
+
+	deleteAggregateRealisasiQuery  = `DELETE FROM agg_realisasi_wilayah WHERE tahun = $1 AND kabupaten = $2`
+	deleteAggregateSumberDanaQuery = `DELETE FROM agg_sumber_dana WHERE tahun = $1 AND kabupaten = $2`
+)
+
+func insertAggregateRealisasiQuery(kode, nama, tingkat string) string {
+	return fmt.Sprintf(`INSERT INTO agg_realisasi_wilayah (
+            tahun, kabupaten, tingkat, kode, nama, jumlah_record, pagu, realisasi,
+            sisa_anggaran, persen_realisasi, persen_fisik
+        )
+        SELECT $1, $2, '%[3]s', %[1]s, max(%[2]s), count(*),
+            COALESCE(sum(pagu), 0),
+            %[4]s,
+            COALESCE(sum(pagu), 0) - %[4]s,
+            CASE WHEN sum(pagu) > 0 THEN round(100 * %[4]s / sum(pagu), 2) END,
+            round(avg(GREATEST(fisik0, fisik1, fisik2))::numeric, 2)
+        %[5]s
+        GROUP BY %[1]s`, kode, nama, tingkat, aggregateRealisasi, aggregateSource)
+}
+
+func insertAggregateSumberDanaQuery(kode, tingkat string) string {
+	return fmt.Sprintf(`INSERT INTO agg_sumber_dana (
+            tahun, kabupaten, tingkat, kode, kode_sumber, jumlah_record, pagu, realisasi
+        )
+        SELECT $1, $2, '%[2]s', %[1]s, COALESCE(kode_sumber, ''), count(*),
+            COALESCE(sum(pagu), 0),
+            %[3]s
+        %[4]s
+        GROUP BY %[1]s, COALESCE(kode_sumber, '')`, kode, tingkat, aggregateRealisasi, aggregateSource)
+}
+
+type dbAggregateRefresher struct {
+	db       *sqlx.DB
+	redactor *redact.Redactor
+}
+
+// NewAggregateRefresher membuat AggregateRefresher yang menulis tabel
+// agg_realisasi_wilayah dan agg_sumber_dana. Migrate membuat tabelnya.
+func NewAggregateRefresher(db *sqlx.DB, r *redact.Redactor) AggregateRefresher {
+	return &dbAggregateRefresher{db: db, redactor: r}
+}
+
+func (a *dbAggregateRefresher) Migrate(ctx context.Context) error {
+	for _, stmt := range aggregateSchema {
+		if _, err := a.db.ExecContext(ctx, stmt); err != nil {
+			return dbError(a.redactor, "migrate", err)
+		}
+	}
+	return nil
+}
+
+// RefreshAggregates menghapus lalu menghitung ulang agregat satu kabupaten
+// di dalam satu transaksi, sehingga dashboard tidak pernah melihat agregat
+// yang setengah jadi dan kabupaten lain tidak tersentuh.
+func (a *dbAggregateRefresher) RefreshAggregates(ctx context.Context, tahun string, kabupaten domain.KodeWilayah) error {
+	tx, err := a.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return dbError(a.redactor, "begin_transaction", err)
+	}
+	defer tx.Rollback() // Aman untuk dipanggil meskipun sudah di-commit.
+
+	kab := kabupaten.Dotted()
+	for _, q := range []string{deleteAggregateRealisasiQuery, deleteAggregateSumberDanaQuery} {
+		if _, err := tx.ExecContext(ctx, q, tahun, kab); err != nil {
+			return dbError(a.redactor, "delete_aggregates", err)
+		}
+	}
+
+	args := []interface{}{
+		tahun, kab, kabupaten.Parent(domain.LevelProvinsi).Dotted(),
+		kabupaten.Segment(), kabupaten.Dotted(), kabupaten.Compact(),
+	}
+	for _, l := range aggregateLevels {
+		if _, err := tx.ExecContext(ctx, insertAggregateRealisasiQuery(l.kode, l.nama, l.tingkat), args...); err != nil {
+			return dbError(a.redactor, "refresh_agg_realisasi_"+l.tingkat, err)
+		}
+		if _, err := tx.ExecContext(ctx, insertAggregateSumberDanaQuery(l.kode, l.tingkat), args...); err != nil {
+			return dbError(a.redactor, "refresh_agg_sumber_dana_"+l.tingkat, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return dbError(a.redactor, "commit_transaction", err)
+	}
+	return nil
+}
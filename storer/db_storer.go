@@ -3,130 +3,108 @@ package storer
 import (
 	"context"
 	"fmt"
-	"strconv"
 
 	"github.com/aryadiwwt/synctodb/domain"
-	customErrors "github.com/aryadiwwt/synctodb/errors"
+	"github.com/aryadiwwt/synctodb/redact"
 
 	"github.com/jmoiron/sqlx"
 )
 
-type Wilayah struct {
-	KodeProvinsi  string `db:"provinsi_id"`
-	KodeKabupaten string `db:"kota_id"`
-}
-
-// Storer mendefinisikan kontrak untuk menyimpan data post.
+// Storer mendefinisikan kontrak untuk menyimpan data output detail.
 type Storer interface {
 	StoreOutputDetails(ctx context.Context, details []domain.OutputDetail) error
-	// Diubah: Menerima slice kode provinsi untuk difilter
-	GetWilayahByProvinsi(ctx context.Context, kodeProvinsi []string) ([]Wilayah, error)
 }
 
-// Implementasi fungsi untuk memfilter berdasarkan kd_prov
-func (s *dbStorer) GetWilayahByProvinsi(ctx context.Context, kodeProvinsi []string) ([]Wilayah, error) {
-	var wilayah []Wilayah
-
-	// Query dasar
-	baseQuery := `SELECT provinsi_id, kota_id FROM master_kota`
-
-	var args []interface{}
-
-	// Jika daftar provinsi diberikan, tambahkan klausa WHERE IN
-	if len(kodeProvinsi) > 0 {
-		baseQuery += ` WHERE provinsi_id IN (?)`
-		args = append(args, kodeProvinsi)
-	}
+type dbStorer struct {
+	db         *sqlx.DB
+	redactor   *redact.Redactor
+	dimensions bool // perbarui tabel dim_* di setiap batch
+	starSchema bool // tulis juga tabel fact_output_detail
+	rowHash    bool // isi row_hash dan lewati baris yang tidak berubah
+}
 
-	baseQuery += ` ORDER BY provinsi_id, kota_id`
+// Option mengatur perilaku opsional dbStorer.
+type Option func(*dbStorer)
 
-	// sqlx.In secara aman akan mengubah query (?) menjadi ($1, $2, ...)
-	// dan menyesuaikan argumennya. Ini cara aman untuk klausa IN.
-	query, args, err := sqlx.In(baseQuery, args...)
-	if err != nil {
-		return nil, fmt.Errorf("gagal membuat query IN: %w", err)
-	}
+// WithRedactor memastikan kredensial database tidak muncul di pesan error.
+func WithRedactor(r *redact.Redactor) Option {
+	return func(s *dbStorer) { s.redactor = r }
+}
 
-	// Rebind query agar sesuai dengan placeholder PostgreSQL ($1, $2)
-	query = s.db.Rebind(query)
+// WithDimensions mengaktifkan pemeliharaan tabel dim_provinsi, dim_kabupaten,
+// dim_kecamatan dan dim_desa dari kode dan nama di setiap batch.
+func WithDimensions() Option {
+	return func(s *dbStorer) { s.dimensions = true }
+}
 
-	err = s.db.SelectContext(ctx, &wilayah, query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("gagal mengambil data wilayah yang difilter: %w", err)
+// WithStarSchema menulis model star-schema (fact_output_detail dengan dimensi
+// kegiatan, sumber dana, output dan PPTKD) di samping tabel datar. Dimensi
+// wilayah dari WithDimensions ikut diaktifkan karena dipakai sebagai kunci.
+func WithStarSchema() Option {
+	return func(s *dbStorer) {
+		s.starSchema = true
+		s.dimensions = true
 	}
+}
 
-	// Lakukan loop untuk memformat kode kabupaten setelah data didapat
-	for i := range wilayah {
-		// Ambil kode kabupaten mentah
-		kodeKabStr := wilayah[i].KodeKabupaten
-
-		// Ubah string menjadi integer
-		num, err := strconv.Atoi(kodeKabStr)
-		if err != nil {
-			// Jika gagal (misal format tidak standar), biarkan apa adanya dan beri peringatan
-			fmt.Printf("Peringatan: Format kd_kab '%s' tidak valid, tidak diformat.\n", kodeKabStr)
-			continue
-		}
+// WithRowHash mengisi kolom row_hash (hash JSON record) dan hanya
+// memperbarui baris yang hash-nya berubah.
+func WithRowHash() Option {
+	return func(s *dbStorer) { s.rowHash = true }
+}
 
-		// Format integer menjadi string 2 digit dengan awalan nol
-		wilayah[i].KodeKabupaten = fmt.Sprintf("%02d", num)
+func NewDBStorer(db *sqlx.DB, opts ...Option) Storer {
+	s := &dbStorer{db: db}
+	for _, opt := range opts {
+		opt(s)
 	}
-
-	return wilayah, nil
+	return s
 }
 
-type dbStorer struct {
-	db *sqlx.DB
+func (s *dbStorer) dbError(operation string, err error) error {
+	return dbError(s.redactor, operation, err)
 }
 
-func NewDBStorer(db *sqlx.DB) Storer {
-	return &dbStorer{db: db}
-}
+// upsertOutputDetailQuery dibangun dari tag db domain.OutputDetail, sehingga
+// kolom baru cukup ditambahkan di struct dan skema. Tanpa WithRowHash,
+// row_hash dikosongkan agar hash lama tidak dibandingkan dengan isi baris
+// yang sudah berubah jika opsi itu diaktifkan lagi.
+var upsertOutputDetailQuery = upsertQuery(OutputDetailTable, Columns[domain.OutputDetail](), "", RowHashColumn)
 
-const (
-	// Query disimpan sebagai konstanta untuk menghindari 'magic strings'
-	// dan memudahkan pengelolaan.
-	upsertOutputDetailQuery = `INSERT INTO siskeudes_detail_output (
-            tahun, kd_prov, nama_provinsi, kd_kab, nama_kabupaten,
-            kd_kec, nama_kecamatan, kd_desa, nama_desa, id_keg,
-            nama_kegiatan, kode_sumber, pagu, kode_output, no_id, nama_paket,
-            lokasi, waktu, keluaran, uraian_output, volume, satuan, nilai,
-            anggaran1, anggaran2, realisasi0, realisasi1, realisasi2, fisik0,
-            fisik1, fisik2, namapptkd, nippptkd, jbtpptkd
-        ) VALUES (
-            :tahun, :kd_prov, :nama_provinsi, :kd_kab, :nama_kabupaten,
-            :kd_kec, :nama_kecamatan, :kd_desa, :nama_desa, :id_keg,
-            :nama_kegiatan, :kode_sumber, :pagu, :kode_output, :no_id, :nama_paket,
-            :lokasi, :waktu, :keluaran, :uraian_output, :volume, :satuan, :nilai,
-            :anggaran1, :anggaran2, :realisasi0, :realisasi1, :realisasi2, :fisik0,
-            :fisik1, :fisik2, :namapptkd, :nippptkd, :jbtpptkd
-        )
-        ON CONFLICT (tahun, kd_prov, kd_kab, kd_kec, kd_desa, id_keg, no_id) DO UPDATE SET
-            nama_provinsi = EXCLUDED.nama_provinsi,
-            pagu = EXCLUDED.pagu,
-            nilai = EXCLUDED.nilai,
-            -- Tambahkan kolom lain yang ingin Anda update jika terjadi konflik
-            nama_paket = EXCLUDED.nama_paket,
-            realisasi1 = EXCLUDED.realisasi1,
-            realisasi2 = EXCLUDED.realisasi2;`
-)
+// upsertChangedOutputDetailQuery dipakai dengan WithRowHash.
+var upsertChangedOutputDetailQuery = UpsertChangedQuery[domain.OutputDetail](OutputDetailTable)
 
-func (s *dbStorer) StoreOutputDetails(ctx context.Context, details []domain.OutputDetail) error {
-	tx, err := s.db.BeginTxx(ctx, nil)
-	if err != nil {
-		return &customErrors.ErrDBOperationFailed{Operation: "begin_transaction", Err: err}
-	}
-	defer tx.Rollback() // Aman untuk dipanggil meskipun sudah di-commit.
+// hashedOutputDetail menambahkan row_hash ke parameter upsert; sqlx
+// membaca field dari struct yang di-embed.
+type hashedOutputDetail struct {
+	domain.OutputDetail
+	RowHash string `db:"row_hash"`
+}
 
-	for _, detail := range details {
-		if _, err := tx.NamedExecContext(ctx, upsertOutputDetailQuery, detail); err != nil {
-			return &customErrors.ErrDBOperationFailed{Operation: "upsert_post", Err: err}
+func (s *dbStorer) StoreOutputDetails(ctx context.Context, details []domain.OutputDetail) error {
+	after := func(tx *sqlx.Tx) error {
+		if s.dimensions {
+			if err := s.upsertDimensions(ctx, tx, details); err != nil {
+				return err
+			}
+		}
+		if s.starSchema {
+			return s.upsertStarSchema(ctx, tx, details)
 		}
+		return nil
 	}
 
-	if err := tx.Commit(); err != nil {
-		return &customErrors.ErrDBOperationFailed{Operation: "commit_transaction", Err: err}
+	if !s.rowHash {
+		return storeInTx(ctx, s.db, s.redactor, "upsert_output_detail", upsertOutputDetailQuery, details, after)
 	}
 
-	return nil
+	hashed := make([]hashedOutputDetail, len(details))
+	for i, d := range details {
+		h, err := RecordHash(d)
+		if err != nil {
+			return fmt.Errorf("gagal menghitung row_hash: %w", err)
+		}
+		hashed[i] = hashedOutputDetail{OutputDetail: d, RowHash: h}
+	}
+	return storeInTx(ctx, s.db, s.redactor, "upsert_output_detail", upsertChangedOutputDetailQuery, hashed, after)
 }
@@ -0,0 +1,178 @@
+package storer
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/aryadiwwt/synctodb/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// dimensionSchema membuat tabel dimensi wilayah dan riwayat perubahan nama.
+var dimensionSchema = []string{
+	`CREATE TABLE IF NOT EXISTS dim_provinsi (
+        kode TEXT PRIMARY KEY,
+        nama TEXT NOT NULL,
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+    )`,
+	`CREATE TABLE IF NOT EXISTS dim_kabupaten (
+        kode TEXT PRIMARY KEY,
+        kd_prov TEXT NOT NULL,
+        nama TEXT NOT NULL,
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+    )`,
+	`CREATE TABLE IF NOT EXISTS dim_kecamatan (
+        kode TEXT PRIMARY KEY,
+        kd_kab TEXT NOT NULL,
+        nama TEXT NOT NULL,
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+    )`,
+	`CREATE TABLE IF NOT EXISTS dim_desa (
+        kode TEXT PRIMARY KEY,
+        kd_kec TEXT NOT NULL,
+        nama TEXT NOT NULL,
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+    )`,
+	`CREATE TABLE IF NOT EXISTS dim_wilayah_perubahan_nama (
+        id BIGSERIAL PRIMARY KEY,
+        tingkat TEXT NOT NULL,
+        kode TEXT NOT NULL,
+        nama_lama TEXT NOT NULL,
+        nama_baru TEXT NOT NULL,
+        terdeteksi_at TIMESTAMPTZ NOT NULL DEFAULT now()
+    )`,
+}
+
+// dimLevel mendeskripsikan satu tingkat wilayah beserta tabel dimensinya.
+// Kolom induk kosong berarti tingkat teratas (provinsi).
+type dimLevel struct {
+	tingkat      string
+	table        string
+	parentColumn string
+}
+
+var dimLevels = []dimLevel{
+	{tingkat: "provinsi", table: "dim_provinsi"},
+	{tingkat: "kabupaten", table: "dim_kabupaten", parentColumn: "kd_prov"},
+	{tingkat: "kecamatan", table: "dim_kecamatan", parentColumn: "kd_kab"},
+	{tingkat: "desa", table: "dim_desa", parentColumn: "kd_kec"},
+}
+
+// upsertQuery menyisipkan atau memperbarui satu baris dimensi. CTE "lama"
+// membaca nama sebelum statement dijalankan, sehingga RETURNING berisi nama
+// lama jika baris diperbarui, NULL jika baris baru, dan tidak mengembalikan
+// baris sama sekali jika tidak ada yang berubah. Nama kosong tidak pernah
+// menimpa nama yang sudah ada.
+func (l dimLevel) upsertQuery() string {
+	if l.parentColumn == "" {
+		return fmt.Sprintf(`WITH lama AS (SELECT nama FROM %[1]s WHERE kode = $1)
+        INSERT INTO %[1]s (kode, nama) VALUES ($1, $2)
+        ON CONFLICT (kode) DO UPDATE SET nama = EXCLUDED.nama, updated_at = now()
+        WHERE EXCLUDED.nama <> '' AND %[1]s.nama IS DISTINCT FROM EXCLUDED.nama
+        RETURNING (SELECT nama FROM lama)`, l.table)
+	}
+	return fmt.Sprintf(`WITH lama AS (SELECT nama FROM %[1]s WHERE kode = $1)
+        INSERT INTO %[1]s (kode, %[2]s, nama) VALUES ($1, $3, $2)
+        ON CONFLICT (kode) DO UPDATE SET %[2]s = EXCLUDED.%[2]s, nama = EXCLUDED.nama, updated_at = now()
+        WHERE EXCLUDED.nama <> '' AND (%[1]s.nama IS DISTINCT FROM EXCLUDED.nama OR %[1]s.%[2]s IS DISTINCT FROM EXCLUDED.%[2]s)
+        RETURNING (SELECT nama FROM lama)`, l.table, l.parentColumn)
+}
+
+const insertPerubahanNamaQuery = `INSERT INTO dim_wilayah_perubahan_nama (tingkat, kode, nama_lama, nama_baru)
+        VALUES ($1, $2, $3, $4)`
+
+// dimRow adalah satu baris dimensi yang akan di-upsert.
+type dimRow struct {
+	kode   string
+	parent string
+	nama   string
+}
+
+// collectDimensions mengumpulkan kode dan nama unik per tingkat wilayah dari
+// satu batch data yang sudah ditransformasi.
+func collectDimensions(details []domain.OutputDetail) [][]dimRow {
+	perLevel := make([]map[string]dimRow, len(dimLevels))
+	for i := range perLevel {
+		perLevel[i] = make(map[string]dimRow)
+	}
+
+	for _, d := range details {
+		kab, kec, desa := dimKode(d)
+		rows := []dimRow{
+			{kode: d.KodeProvinsi, nama: d.NamaProvinsi},
+			{kode: kab, parent: d.KodeProvinsi, nama: d.NamaKabupaten},
+			{kode: kec, parent: kab, nama: d.NamaKecamatan},
+			{kode: desa, parent: kec, nama: d.NamaDesa},
+		}
+		for i, row := range rows {
+			if row.kode == "" {
+				continue
+			}
+			// Baris dengan nama kosong tetap disimpan (misal sebagai induk
+			// tabel fakta), tetapi tidak menggantikan nama yang sudah terisi.
+			if existing, ok := perLevel[i][row.kode]; ok && row.nama == "" {
+				row.nama = existing.nama
+			}
+			perLevel[i][row.kode] = row
+		}
+	}
+
+	out := make([][]dimRow, len(dimLevels))
+	for i, m := range perLevel {
+		for _, row := range m {
+			out[i] = append(out[i], row)
+		}
+		// Urutan tetap agar lock baris selalu diambil dengan urutan yang sama.
+		sort.Slice(out[i], func(a, b int) bool { return out[i][a].kode < out[i][b].kode })
+	}
+	return out
+}
+
+// dimKode mengembalikan kode lengkap bertitik untuk tabel dimensi, apa pun
+// profil transformasi yang dipakai. Kode yang tidak bisa di-parse dipakai
+// apa adanya.
+func dimKode(d domain.OutputDetail) (kab, kec, desa string) {
+	k, c, ds, err := d.QualifiedKode()
+	if err != nil {
+		return d.KodeKabupaten, d.KodeKecamatan, d.KodeDesa
+	}
+	return k.Dotted(), c.Dotted(), ds.Dotted()
+}
+
+// upsertDimensions memperbarui tabel dimensi wilayah di dalam transaksi yang
+// sama dengan upsert data utama, dan mencatat setiap perubahan nama.
+func (s *dbStorer) upsertDimensions(ctx context.Context, tx *sqlx.Tx, details []domain.OutputDetail) error {
+	for i, rows := range collectDimensions(details) {
+		level := dimLevels[i]
+		query := level.upsertQuery()
+
+		for _, row := range rows {
+			args := []interface{}{row.kode, row.nama}
+			if level.parentColumn != "" {
+				args = append(args, row.parent)
+			}
+
+			var namaLama sql.NullString
+			err := tx.QueryRowxContext(ctx, query, args...).Scan(&namaLama)
+			if err == sql.ErrNoRows {
+				continue // tidak ada perubahan
+			}
+			if err != nil {
+				return s.dbError("upsert_"+level.table, err)
+			}
+			if !namaLama.Valid || namaLama.String == "" || namaLama.String == row.nama {
+				continue // baris baru, nama baru terisi, atau hanya induknya yang berubah
+			}
+
+			log.Printf("Perubahan nama %s %s: %q -> %q", level.tingkat, row.kode, namaLama.String, row.nama)
+			if _, err := tx.ExecContext(ctx, insertPerubahanNamaQuery, level.tingkat, row.kode, namaLama.String, row.nama); err != nil {
+				return s.dbError("insert_perubahan_nama", err)
+			}
+		}
+	}
+	return nil
+}
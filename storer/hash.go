@@ -0,0 +1,112 @@
+package storer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+
+	"github.com/aryadiwwt/synctodb/redact"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// RecordHash mengembalikan SHA-256 (hex) dari representasi JSON record.
+// Urutan field JSON mengikuti deklarasi struct sehingga hasilnya stabil.
+func RecordHash(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// ContentHash menghitung hash satu set record yang tidak bergantung pada
+// urutan record dari API: hash setiap record diurutkan lalu di-hash ulang.
+func ContentHash[T any](records []T) (string, error) {
+	hashes := make([]string, len(records))
+	for i, r := range records {
+		h, err := RecordHash(r)
+		if err != nil {
+			return "", err
+		}
+		hashes[i] = h
+	}
+	sort.Strings(hashes)
+
+	h := sha256.New()
+	for _, s := range hashes {
+		h.Write([]byte(s))
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ContentHashStore menyimpan content hash terakhir per resource, tahun dan
+// kabupaten/kota.
+type ContentHashStore interface {
+	// ContentHash mengembalikan string kosong jika belum pernah disimpan.
+	ContentHash(ctx context.Context, resource, tahun, kabupaten string) (string, error)
+	SaveContentHash(ctx context.Context, resource, tahun, kabupaten, hash string, count int) error
+}
+
+// contentHashSchema membuat tabel sync_content_hash.
+var contentHashSchema = []string{
+	`CREATE TABLE IF NOT EXISTS sync_content_hash (
+        resource TEXT NOT NULL,
+        tahun TEXT NOT NULL,
+        kabupaten TEXT NOT NULL,
+        hash TEXT NOT NULL,
+        jumlah_record INTEGER NOT NULL,
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+        PRIMARY KEY (resource, tahun, kabupaten)
+    )`,
+}
+
+const (
+	selectContentHashQuery = `SELECT hash FROM sync_content_hash WHERE resource = $1 AND tahun = $2 AND kabupaten = $3`
+	upsertContentHashQuery = `INSERT INTO sync_content_hash (resource, tahun, kabupaten, hash, jumlah_record, updated_at)
+        VALUES ($1, $2, $3, $4, $5, now())
+        ON CONFLICT (resource, tahun, kabupaten) DO UPDATE SET
+            hash = EXCLUDED.hash, jumlah_record = EXCLUDED.jumlah_record, updated_at = EXCLUDED.updated_at`
+)
+
+type dbContentHashStore struct {
+	db       *sqlx.DB
+	redactor *redact.Redactor
+}
+
+// NewContentHashStore membuat ContentHashStore di tabel sync_content_hash.
+// Migrate membuat tabelnya.
+func NewContentHashStore(db *sqlx.DB, r *redact.Redactor) ContentHashStore {
+	return &dbContentHashStore{db: db, redactor: r}
+}
+
+func (s *dbContentHashStore) Migrate(ctx context.Context) error {
+	for _, stmt := range contentHashSchema {
+		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+			return dbError(s.redactor, "migrate", err)
+		}
+	}
+	return nil
+}
+
+func (s *dbContentHashStore) ContentHash(ctx context.Context, resource, tahun, kabupaten string) (string, error) {
+	var hashes []string
+	if err := s.db.SelectContext(ctx, &hashes, selectContentHashQuery, resource, tahun, kabupaten); err != nil {
+		return "", dbError(s.redactor, "select_content_hash", err)
+	}
+	if len(hashes) == 0 {
+		return "", nil
+	}
+	return hashes[0], nil
+}
+
+func (s *dbContentHashStore) SaveContentHash(ctx context.Context, resource, tahun, kabupaten, hash string, count int) error {
+	if _, err := s.db.ExecContext(ctx, upsertContentHashQuery, resource, tahun, kabupaten, hash, count); err != nil {
+		return dbError(s.redactor, "save_content_hash", err)
+	}
+	return nil
+}
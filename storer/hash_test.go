@@ -0,0 +1,125 @@
+package storer
+
+import (
+	"testing"
+
+	"github.com/aryadiwwt/synctodb/domain"
+)
+
+func hashRecords() []domain.OutputDetail {
+	pagu, _ := domain.ParseDecimal("1500000.00")
+	return []domain.OutputDetail{
+		{Tahun: "2024", KodeKabupaten: "51.03", KodeDesa: "51.03.03.2001", IDKegiatan: "K1", NoID: "1", Pagu: pagu, Fisik0: 50},
+		{Tahun: "2024", KodeKabupaten: "51.03", KodeDesa: "51.03.03.2001", IDKegiatan: "K1", NoID: "2", Pagu: pagu},
+		{Tahun: "2024", KodeKabupaten: "51.03", KodeDesa: "51.03.03.2002", IDKegiatan: "K2", NoID: "1", NamaPaket: "Jalan"},
+	}
+}
+
+func TestContentHash(t *testing.T) {
+	base := hashRecords()
+	baseHash, err := ContentHash(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		records  func() []domain.OutputDetail
+		wantSame bool
+	}{
+		{name: "urutan sama", records: hashRecords, wantSame: true},
+		{
+			name: "urutan dibalik",
+			records: func() []domain.OutputDetail {
+				r := hashRecords()
+				r[0], r[2] = r[2], r[0]
+				return r
+			},
+			wantSame: true,
+		},
+		{
+			name: "urutan diputar",
+			records: func() []domain.OutputDetail {
+				r := hashRecords()
+				return append(r[1:], r[0])
+			},
+			wantSame: true,
+		},
+		{
+			name: "coercions tidak ikut di-hash",
+			records: func() []domain.OutputDetail {
+				r := hashRecords()
+				r[1].Coercions = []string{`pagu: locale ("1.500.000,00")`}
+				return r
+			},
+			wantSame: true,
+		},
+		{
+			name: "satu field teks berubah",
+			records: func() []domain.OutputDetail {
+				r := hashRecords()
+				r[2].NamaPaket = "Jalan Desa"
+				return r
+			},
+		},
+		{
+			name: "satu angka berubah",
+			records: func() []domain.OutputDetail {
+				r := hashRecords()
+				r[0].Fisik0 = 51
+				return r
+			},
+		},
+		{
+			name: "record berpindah kunci",
+			records: func() []domain.OutputDetail {
+				r := hashRecords()
+				r[0].NoID, r[1].NoID = r[1].NoID, r[0].NoID
+				return r
+			},
+		},
+		{
+			name: "record hilang",
+			records: func() []domain.OutputDetail {
+				return hashRecords()[:2]
+			},
+		},
+		{
+			name: "record ganda",
+			records: func() []domain.OutputDetail {
+				r := hashRecords()
+				return append(r, r[0])
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ContentHash(tt.records())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (got == baseHash) != tt.wantSame {
+				t.Errorf("hash sama = %v, want %v", got == baseHash, tt.wantSame)
+			}
+		})
+	}
+}
+
+func TestRecordHash(t *testing.T) {
+	a := hashRecords()[0]
+	b := hashRecords()[0]
+	ha, err := RecordHash(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hb, _ := RecordHash(b); ha != hb {
+		t.Error("record yang sama harus menghasilkan hash yang sama")
+	}
+	b.NamaDesa = "DALUNG"
+	if hb, _ := RecordHash(b); ha == hb {
+		t.Error("perubahan satu field harus mengubah hash")
+	}
+	if len(ha) != 64 {
+		t.Errorf("panjang hash = %d, want 64 (hex SHA-256)", len(ha))
+	}
+}
@@ -0,0 +1,257 @@
+package storer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+
+	"github.com/aryadiwwt/synctodb/domain"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+const (
+	// parquetMoneyScale dan parquetMoneyPrecision sama dengan NUMERIC(20,2)
+	// di Postgres, tetapi presisi dibatasi 18 agar muat di INT64.
+	parquetMoneyScale     = 2
+	parquetMoneyPrecision = 18
+
+	// parquetPartitionFile adalah nama file data di setiap partisi.
+	parquetPartitionFile = "part-0.parquet"
+)
+
+// parquetColumn memetakan satu kolom schema Parquet ke field OutputDetail.
+type parquetColumn struct {
+	field int
+	kind  reflect.Kind
+	money bool
+}
+
+var decimalType = reflect.TypeOf(domain.Decimal{})
+
+// outputDetailParquetSchema membangun schema Parquet dari tag db
+// domain.OutputDetail: string menjadi STRING, float64 menjadi DOUBLE, dan
+// domain.Decimal menjadi DECIMAL(18,2) di atas INT64. Kolom dikembalikan
+// dengan urutan leaf schema (parquet.Group mengurutkan berdasarkan nama).
+func outputDetailParquetSchema() (*parquet.Schema, []parquetColumn) {
+	t := reflect.TypeOf(domain.OutputDetail{})
+	group := parquet.Group{}
+	byName := make(map[string]parquetColumn)
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := dbColumnName(f)
+		if name == "" {
+			continue
+		}
+		switch {
+		case f.Type == decimalType:
+			group[name] = parquet.Decimal(parquetMoneyScale, parquetMoneyPrecision, parquet.Int64Type)
+			byName[name] = parquetColumn{field: i, money: true}
+		case f.Type.Kind() == reflect.Float64:
+			group[name] = parquet.Leaf(parquet.DoubleType)
+			byName[name] = parquetColumn{field: i, kind: reflect.Float64}
+		case f.Type.Kind() == reflect.String:
+			group[name] = parquet.String()
+			byName[name] = parquetColumn{field: i, kind: reflect.String}
+		default:
+			panic(fmt.Sprintf("storer: tipe %s untuk kolom %s belum didukung parquet", f.Type, name))
+		}
+	}
+
+	schema := parquet.NewSchema("output_detail", group)
+	columns := make([]parquetColumn, 0, len(byName))
+	for _, f := range schema.Fields() {
+		columns = append(columns, byName[f.Name()])
+	}
+	return schema, columns
+}
+
+func dbColumnName(f reflect.StructField) string {
+	name := f.Tag.Get("db")
+	if name == "-" || !f.IsExported() {
+		return ""
+	}
+	return name
+}
+
+// parquetStorer menulis setiap batch ke file Parquet yang dipartisi
+// tahun=/kd_prov=/kd_kab=. Setiap partisi berisi satu file yang diganti
+// secara atomik saat kabupaten yang sama disinkronkan ulang.
+type parquetStorer struct {
+	dir     string
+	schema  *parquet.Schema
+	columns []parquetColumn
+}
+
+// NewParquetStorer membuat Storer yang menulis ke direktori dir.
+func NewParquetStorer(dir string) Storer {
+	schema, columns := outputDetailParquetSchema()
+	return &parquetStorer{dir: dir, schema: schema, columns: columns}
+}
+
+// parquetPartition adalah kunci partisi satu record.
+type parquetPartition struct {
+	tahun, prov, kab string
+}
+
+func (p parquetPartition) path(dir string) string {
+	return filepath.Join(dir, "tahun="+p.tahun, "kd_prov="+p.prov, "kd_kab="+p.kab)
+}
+
+// partitionOf memakai segmen kode kabupaten ("03") agar nama partisi sama
+// apa pun profil transformasi yang dipakai.
+func partitionOf(d domain.OutputDetail) parquetPartition {
+	kab := d.KodeKabupaten
+	if k, _, _, err := d.QualifiedKode(); err == nil {
+		kab = k.Segment()
+	}
+	return parquetPartition{tahun: d.Tahun, prov: d.KodeProvinsi, kab: kab}
+}
+
+// StoreOutputDetails mengganti seluruh isi partisi yang disentuh batch ini.
+// Satu batch dari synchronizer berisi tepat satu kabupaten.
+func (s *parquetStorer) StoreOutputDetails(ctx context.Context, details []domain.OutputDetail) error {
+	groups := make(map[parquetPartition][]domain.OutputDetail)
+	for _, d := range details {
+		p := partitionOf(d)
+		groups[p] = append(groups[p], d)
+	}
+
+	partitions := make([]parquetPartition, 0, len(groups))
+	for p := range groups {
+		partitions = append(partitions, p)
+	}
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i].path("") < partitions[j].path("") })
+
+	for _, p := range partitions {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.writePartition(p, groups[p]); err != nil {
+			return fmt.Errorf("gagal menulis partisi parquet %s: %w", p.path(s.dir), err)
+		}
+	}
+	return nil
+}
+
+// writePartition menulis ke file sementara di direktori partisi lalu
+// me-rename-nya menimpa file lama. Rename di filesystem yang sama bersifat
+// atomik, sehingga pembaca selalu melihat isi lama atau isi baru secara utuh.
+// File sementara diawali titik agar diabaikan oleh pembaca Parquet.
+func (s *parquetStorer) writePartition(p parquetPartition, details []domain.OutputDetail) error {
+	dir := p.path(s.dir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+parquetPartitionFile+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // tidak berpengaruh setelah rename berhasil
+
+	w := parquet.NewWriter(tmp, s.schema, parquet.Compression(&parquet.Snappy))
+	rows := make([]parquet.Row, 0, len(details))
+	for _, d := range details {
+		row, err := s.row(d)
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		rows = append(rows, row)
+	}
+	if _, err := w.WriteRows(rows); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, parquetPartitionFile))
+}
+
+func (s *parquetStorer) row(d domain.OutputDetail) (parquet.Row, error) {
+	v := reflect.ValueOf(d)
+	row := make(parquet.Row, len(s.columns))
+	for i, c := range s.columns {
+		f := v.Field(c.field)
+		var value parquet.Value
+		switch {
+		case c.money:
+			dec := f.Interface().(domain.Decimal)
+			unscaled, ok := dec.UnscaledInt64(parquetMoneyScale)
+			if !ok {
+				return nil, fmt.Errorf("nilai %s di luar jangkauan DECIMAL(%d,%d)", dec, parquetMoneyPrecision, parquetMoneyScale)
+			}
+			value = parquet.Int64Value(unscaled)
+		case c.kind == reflect.Float64:
+			value = parquet.DoubleValue(f.Float())
+		default:
+			value = parquet.ByteArrayValue([]byte(f.String()))
+		}
+		row[i] = value.Level(0, 0, i)
+	}
+	return row, nil
+}
+
+// multiStorer meneruskan setiap batch ke beberapa Storer secara berurutan,
+// misal Postgres lalu Parquet.
+type multiStorer struct {
+	storers []Storer
+}
+
+// NewMultiStorer membuat Storer yang menulis ke semua storers. Penulisan
+// berhenti di error pertama; storer sebelumnya tidak di-rollback, tetapi
+// karena setiap storer idempoten, sinkronisasi ulang akan menyamakannya.
+func NewMultiStorer(storers ...Storer) Storer {
+	if len(storers) == 1 {
+		return storers[0]
+	}
+	return &multiStorer{storers: storers}
+}
+
+func (m *multiStorer) StoreOutputDetails(ctx context.Context, details []domain.OutputDetail) error {
+	for _, s := range m.storers {
+		if err := s.StoreOutputDetails(ctx, details); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpgradeSchema menjalankan UpgradeSchema untuk setiap storer yang
+// mendukungnya.
+func (m *multiStorer) UpgradeSchema(ctx context.Context) error {
+	for _, s := range m.storers {
+		if u, ok := s.(SchemaUpgrader); ok {
+			if err := u.UpgradeSchema(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Migrate menjalankan migrasi untuk setiap storer yang mendukungnya.
+func (m *multiStorer) Migrate(ctx context.Context) error {
+	for _, s := range m.storers {
+		if mig, ok := s.(Migrator); ok {
+			if err := mig.Migrate(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package storer
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/aryadiwwt/synctodb/domain"
+	"github.com/aryadiwwt/synctodb/redact"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// OutputDetailFilter membatasi baris yang dibaca OutputDetailReader. Field
+// kosong berarti tidak difilter.
+type OutputDetailFilter struct {
+	Tahun string
+	// Wilayah bisa di tingkat mana pun, dari provinsi sampai desa.
+	Wilayah    domain.KodeWilayah
+	KodeSumber []string
+}
+
+// OutputDetailReader membaca output detail yang tersimpan secara streaming,
+// terurut berdasarkan wilayah (provinsi, kabupaten, kecamatan, desa), agar
+// ekspor nasional tidak perlu memuat semua baris ke memori.
+type OutputDetailReader interface {
+	StreamOutputDetails(ctx context.Context, filter OutputDetailFilter, fn func(domain.OutputDetail) error) error
+}
+
+type dbOutputDetailReader struct {
+	db       *sqlx.DB
+	redactor *redact.Redactor
+}
+
+// NewOutputDetailReader membuat OutputDetailReader dari tabel
+// siskeudes_detail_output.
+func NewOutputDetailReader(db *sqlx.DB, r *redact.Redactor) OutputDetailReader {
+	return &dbOutputDetailReader{db: db, redactor: r}
+}
+
+func (s *dbOutputDetailReader) StreamOutputDetails(ctx context.Context, filter OutputDetailFilter, fn func(domain.OutputDetail) error) error {
+	query, args, err := outputDetailSelect(filter)
+	if err != nil {
+		return err
+	}
+
+	rows, err := s.db.QueryxContext(ctx, s.db.Rebind(query), args...)
+	if err != nil {
+		return dbError(s.redactor, "select_output_detail", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d domain.OutputDetail
+		if err := rows.StructScan(&d); err != nil {
+			return dbError(s.redactor, "scan_output_detail", err)
+		}
+		if err := fn(d); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return dbError(s.redactor, "select_output_detail", err)
+	}
+	return nil
+}
+
+// outputDetailSelect membangun query SELECT dengan placeholder "?" yang
+// sudah dikembangkan oleh sqlx.In.
+func outputDetailSelect(filter OutputDetailFilter) (string, []interface{}, error) {
+	var where []string
+	var args []interface{}
+
+	if filter.Tahun != "" {
+		where = append(where, "tahun = ?")
+		args = append(args, filter.Tahun)
+	}
+
+	// Kode tersimpan dalam bentuk hasil profil transformasi (bertitik, tanpa
+	// titik atau mentah), sehingga setiap tingkat dicocokkan dengan semua
+	// bentuk yang mungkin di kolom kode maupun kolom *_raw.
+	w := filter.Wilayah
+	if w.Level() >= domain.LevelProvinsi {
+		where = append(where, "kd_prov = ?")
+		args = append(args, w.Parent(domain.LevelProvinsi).Dotted())
+	}
+	levels := []struct {
+		level  int
+		column string
+	}{
+		{domain.LevelKabupaten, "kd_kab"},
+		{domain.LevelKecamatan, "kd_kec"},
+		{domain.LevelDesa, "kd_desa"},
+	}
+	for _, l := range levels {
+		if w.Level() < l.level {
+			break
+		}
+		forms := kodeForms(w.Parent(l.level))
+		where = append(where, fmt.Sprintf("(%[1]s IN (?) OR %[1]s_raw IN (?))", l.column))
+		args = append(args, forms, forms)
+	}
+
+	if len(filter.KodeSumber) > 0 {
+		where = append(where, "kode_sumber IN (?)")
+		args = append(args, filter.KodeSumber)
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(nullSafeColumns[domain.OutputDetail](), ", "), OutputDetailTable.Name)
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY kd_prov, kd_kab, kd_kec, kd_desa, id_keg, no_id"
+
+	if len(args) == 0 {
+		return query, nil, nil
+	}
+	return sqlx.In(query, args...)
+}
+
+// nullSafeColumns mengembalikan daftar kolom SELECT untuk T. Kolom string dan
+// angka dibungkus COALESCE karena baris lama bisa berisi NULL, misal kd_*_raw
+// yang baru ditambahkan UpgradeSchema atau fisik0-2 yang memang nullable,
+// sedangkan field-nya bukan tipe nullable. Field lain (misal domain.Decimal)
+// membaca NULL sendiri lewat Scan.
+func nullSafeColumns[T any]() []string {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	var cols []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("db"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		switch f.Type.Kind() {
+		case reflect.String:
+			name = fmt.Sprintf("COALESCE(%[1]s, '') AS %[1]s", name)
+		case reflect.Int, reflect.Int64, reflect.Float64:
+			name = fmt.Sprintf("COALESCE(%[1]s, 0) AS %[1]s", name)
+		}
+		cols = append(cols, name)
+	}
+	return cols
+}
+
+// kodeForms mengembalikan semua bentuk penyimpanan sebuah kode: bertitik,
+// tanpa titik, dan bentuk relatif seperti yang dikirim API ("03" untuk
+// kabupaten/kecamatan, "03.2001" dan "03.2001." untuk desa).
+func kodeForms(k domain.KodeWilayah) []string {
+	forms := []string{k.Dotted(), k.Compact(), k.Segment()}
+	if k.Level() == domain.LevelDesa {
+		relative := k.Parent(domain.LevelKecamatan).Segment() + "." + k.Segment()
+		forms = []string{k.Dotted(), k.Compact(), relative, relative + "."}
+	}
+	return forms
+}
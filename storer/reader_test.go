@@ -0,0 +1,179 @@
+package storer
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/aryadiwwt/synctodb/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// fakeRowsDB adalah driver database/sql minimal yang menjawab setiap query
+// dengan baris tetap. Daftar kolom diambil dari SELECT dan COALESCE
+// dievaluasi seperti di Postgres, sehingga baris dengan NULL bisa diuji
+// tanpa database sungguhan.
+type fakeRowsDB struct {
+	rows []map[string]driver.Value
+}
+
+var selectItem = regexp.MustCompile(`COALESCE\((\w+), ([^)]*)\) AS (\w+)|\w+`)
+
+func (db fakeRowsDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{db}, nil }
+func (db fakeRowsDB) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ db fakeRowsDB }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{c.db, query}, nil }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return nil, fmt.Errorf("not supported") }
+
+type fakeStmt struct {
+	db    fakeRowsDB
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+func (s fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func (s fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	list := s.query[len("SELECT "):strings.Index(s.query, " FROM ")]
+	r := &fakeRows{}
+	for _, m := range selectItem.FindAllStringSubmatch(list, -1) {
+		col, def, alias := m[0], "", m[0]
+		if m[1] != "" {
+			col, def, alias = m[1], m[2], m[3]
+		}
+		r.cols = append(r.cols, alias)
+		r.get = append(r.get, func(row map[string]driver.Value) driver.Value {
+			v := row[col]
+			switch {
+			case v != nil || def == "":
+				return v
+			case def == "''":
+				return ""
+			default:
+				return int64(0)
+			}
+		})
+	}
+	r.rows = s.db.rows
+	return r, nil
+}
+
+type fakeRows struct {
+	cols []string
+	get  []func(map[string]driver.Value) driver.Value
+	rows []map[string]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.cols }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	for i, get := range r.get {
+		dest[i] = get(r.rows[0])
+	}
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestStreamOutputDetailsNullColumns(t *testing.T) {
+	base := map[string]driver.Value{
+		"tahun": "2024", "kd_prov": "51", "kd_kab": "51.03", "kd_kec": "51.03.03",
+		"kd_desa": "51.03.03.2001", "id_keg": "K1", "no_id": "1",
+	}
+	with := func(extra map[string]driver.Value) map[string]driver.Value {
+		row := make(map[string]driver.Value)
+		for k, v := range base {
+			row[k] = v
+		}
+		for k, v := range extra {
+			row[k] = v
+		}
+		return row
+	}
+
+	tests := []struct {
+		name string
+		row  map[string]driver.Value
+		want func(domain.OutputDetail) error
+	}{
+		{
+			name: "baris lama tanpa kode mentah dan fisik",
+			row:  with(nil),
+			want: func(d domain.OutputDetail) error {
+				if d.KodeKabupatenRaw != "" || d.KodeKecamatanRaw != "" || d.KodeDesaRaw != "" {
+					return fmt.Errorf("kode mentah = %q/%q/%q, want kosong", d.KodeKabupatenRaw, d.KodeKecamatanRaw, d.KodeDesaRaw)
+				}
+				if d.Fisik0 != 0 || d.Volume != 0 || !d.Pagu.IsZero() {
+					return fmt.Errorf("angka = %v/%v/%s, want nol", d.Fisik0, d.Volume, d.Pagu)
+				}
+				return nil
+			},
+		},
+		{
+			name: "baris baru",
+			row: with(map[string]driver.Value{
+				"kd_kab_raw": "03", "kd_desa_raw": "03.2001.", "fisik1": 75.5, "pagu": "1500000.00", "nama_desa": "DALUNG",
+			}),
+			want: func(d domain.OutputDetail) error {
+				if d.KodeKabupatenRaw != "03" || d.KodeDesaRaw != "03.2001." || d.NamaDesa != "DALUNG" {
+					return fmt.Errorf("kolom teks = %q/%q/%q", d.KodeKabupatenRaw, d.KodeDesaRaw, d.NamaDesa)
+				}
+				if d.Fisik1 != 75.5 || d.Pagu.String() != "1500000.00" {
+					return fmt.Errorf("angka = %v/%s", d.Fisik1, d.Pagu)
+				}
+				return nil
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := sqlx.NewDb(sql.OpenDB(fakeRowsDB{rows: []map[string]driver.Value{tt.row}}), "postgres")
+			defer db.Close()
+
+			var got []domain.OutputDetail
+			err := NewOutputDetailReader(db, nil).StreamOutputDetails(context.Background(), OutputDetailFilter{}, func(d domain.OutputDetail) error {
+				got = append(got, d)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("StreamOutputDetails() error = %v", err)
+			}
+			if len(got) != 1 {
+				t.Fatalf("got %d rows, want 1", len(got))
+			}
+			if err := tt.want(got[0]); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestNullSafeColumns(t *testing.T) {
+	cols := strings.Join(nullSafeColumns[domain.OutputDetail](), ", ")
+	for _, want := range []string{
+		"COALESCE(kd_kab_raw, '') AS kd_kab_raw",
+		"COALESCE(fisik0, 0) AS fisik0",
+		"COALESCE(volume, 0) AS volume",
+	} {
+		if !strings.Contains(cols, want) {
+			t.Errorf("kolom SELECT tidak memuat %q", want)
+		}
+	}
+	if strings.Contains(cols, "COALESCE(pagu") {
+		t.Error("kolom Decimal tidak perlu COALESCE")
+	}
+}
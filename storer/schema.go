@@ -0,0 +1,135 @@
+package storer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Migrator diimplementasikan oleh storer yang bisa membuat tabel
+// pendukungnya sendiri. Semua statement bersifat idempoten sehingga aman
+// dijalankan di setiap start.
+type Migrator interface {
+	Migrate(ctx context.Context) error
+}
+
+// SchemaUpgrader diimplementasikan oleh storer yang menulis kolom yang
+// mungkin belum ada di tabel lama. UpgradeSchema dijalankan di setiap start,
+// juga tanpa database.auto_migrate, dan hanya menambahkan kolom yang hilang.
+type SchemaUpgrader interface {
+	UpgradeSchema(ctx context.Context) error
+}
+
+// outputDetailAddedColumns adalah kolom siskeudes_detail_output yang
+// ditambahkan setelah tabel awal dibuat: kode wilayah asli API dan hash per
+// record untuk WithRowHash.
+var outputDetailAddedColumns = []string{"kd_kab_raw", "kd_kec_raw", "kd_desa_raw", "row_hash"}
+
+// outputDetailSchema membuat tabel utama jika belum ada. Unique constraint
+// harus sama dengan OutputDetailTable.BusinessKey (target ON CONFLICT).
+var outputDetailSchema = append([]string{
+	`CREATE TABLE IF NOT EXISTS siskeudes_detail_output (
+        id BIGSERIAL PRIMARY KEY,
+        tahun TEXT NOT NULL,
+        kd_prov TEXT NOT NULL,
+        nama_provinsi TEXT,
+        kd_kab TEXT NOT NULL,
+        nama_kabupaten TEXT,
+        kd_kec TEXT NOT NULL,
+        nama_kecamatan TEXT,
+        kd_desa TEXT NOT NULL,
+        nama_desa TEXT,
+        id_keg TEXT NOT NULL,
+        nama_kegiatan TEXT,
+        kode_sumber TEXT,
+        pagu NUMERIC(20,2),
+        kode_output TEXT,
+        no_id TEXT NOT NULL,
+        nama_paket TEXT,
+        lokasi TEXT,
+        waktu TEXT,
+        keluaran TEXT,
+        uraian_output TEXT,
+        volume DOUBLE PRECISION,
+        satuan TEXT,
+        nilai NUMERIC(20,2),
+        anggaran1 NUMERIC(20,2),
+        anggaran2 NUMERIC(20,2),
+        realisasi0 NUMERIC(20,2),
+        realisasi1 NUMERIC(20,2),
+        realisasi2 NUMERIC(20,2),
+        fisik0 DOUBLE PRECISION,
+        fisik1 DOUBLE PRECISION,
+        fisik2 DOUBLE PRECISION,
+        namapptkd TEXT,
+        nippptkd TEXT,
+        jbtpptkd TEXT,
+        kd_kab_raw TEXT,
+        kd_kec_raw TEXT,
+        kd_desa_raw TEXT,
+        row_hash TEXT,
+        CONSTRAINT uq_output_detail_business_key UNIQUE (tahun, kd_prov, kd_kab, kd_kec, kd_desa, id_keg, no_id)
+    )`,
+}, addOutputDetailColumns(outputDetailAddedColumns)...)
+
+// addOutputDetailColumns membuat ALTER untuk tabel yang dibuat sebelum kolom
+// tersebut ada.
+func addOutputDetailColumns(columns []string) []string {
+	statements := make([]string, len(columns))
+	for i, c := range columns {
+		statements[i] = fmt.Sprintf(`ALTER TABLE siskeudes_detail_output ADD COLUMN IF NOT EXISTS %s TEXT`, c)
+	}
+	return statements
+}
+
+const outputDetailColumnsQuery = `SELECT column_name FROM information_schema.columns
+    WHERE table_schema = current_schema() AND table_name = 'siskeudes_detail_output'`
+
+// UpgradeSchema menambahkan kolom outputDetailAddedColumns yang belum ada.
+// Kolom diperiksa dulu lewat information_schema agar user database tanpa hak
+// ALTER tidak gagal saat tabelnya sudah lengkap. Tabel yang belum ada
+// dibiarkan untuk Migrate atau DDL manual.
+func (s *dbStorer) UpgradeSchema(ctx context.Context) error {
+	var existing []string
+	if err := s.db.SelectContext(ctx, &existing, outputDetailColumnsQuery); err != nil {
+		return s.dbError("check_columns", err)
+	}
+	if len(existing) == 0 {
+		return nil
+	}
+	has := make(map[string]bool, len(existing))
+	for _, c := range existing {
+		has[c] = true
+	}
+
+	var missing []string
+	for _, c := range outputDetailAddedColumns {
+		if !has[c] {
+			missing = append(missing, c)
+		}
+	}
+	for _, stmt := range addOutputDetailColumns(missing) {
+		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("kolom baru siskeudes_detail_output belum ada dan tidak bisa ditambahkan otomatis; jalankan sebagai pemilik tabel: %s: %w",
+				strings.Join(addOutputDetailColumns(missing), "; "), s.dbError("add_columns", err))
+		}
+	}
+	return nil
+}
+
+func (s *dbStorer) Migrate(ctx context.Context) error {
+	statements := append([]string(nil), outputDetailSchema...)
+	if s.dimensions {
+		statements = append(statements, dimensionSchema...)
+	}
+	if s.starSchema {
+		statements = append(statements, starSchema...)
+	}
+
+	for _, stmt := range statements {
+		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+			return s.dbError("migrate", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package storer
+
+import (
+	"context"
+
+	"github.com/aryadiwwt/synctodb/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// starSchema membuat tabel fakta dan dimensi non-wilayah untuk kebutuhan BI.
+// Dimensi wilayah memakai dim_desa dari dimensionSchema (natural key kode).
+var starSchema = []string{
+	`CREATE TABLE IF NOT EXISTS dim_kegiatan (
+        id BIGSERIAL PRIMARY KEY,
+        id_keg TEXT NOT NULL UNIQUE,
+        nama_kegiatan TEXT
+    )`,
+	`CREATE TABLE IF NOT EXISTS dim_sumber_dana (
+        id BIGSERIAL PRIMARY KEY,
+        kode_sumber TEXT NOT NULL UNIQUE
+    )`,
+	`CREATE TABLE IF NOT EXISTS dim_output (
+        id BIGSERIAL PRIMARY KEY,
+        kode_output TEXT NOT NULL,
+        satuan TEXT NOT NULL DEFAULT '',
+        uraian_output TEXT,
+        UNIQUE (kode_output, satuan)
+    )`,
+	`CREATE TABLE IF NOT EXISTS dim_pptkd (
+        id BIGSERIAL PRIMARY KEY,
+        nip TEXT NOT NULL DEFAULT '',
+        nama TEXT NOT NULL DEFAULT '',
+        jabatan TEXT NOT NULL DEFAULT '',
+        UNIQUE (nip, nama, jabatan)
+    )`,
+	`CREATE TABLE IF NOT EXISTS fact_output_detail (
+        id BIGSERIAL PRIMARY KEY,
+        tahun TEXT NOT NULL,
+        kd_desa TEXT NOT NULL REFERENCES dim_desa (kode),
+        kegiatan_id BIGINT NOT NULL REFERENCES dim_kegiatan (id),
+        sumber_dana_id BIGINT REFERENCES dim_sumber_dana (id),
+        output_id BIGINT REFERENCES dim_output (id),
+        pptkd_id BIGINT REFERENCES dim_pptkd (id),
+        no_id TEXT NOT NULL,
+        nama_paket TEXT,
+        pagu NUMERIC(20,2),
+        nilai NUMERIC(20,2),
+        anggaran1 NUMERIC(20,2),
+        anggaran2 NUMERIC(20,2),
+        realisasi0 NUMERIC(20,2),
+        realisasi1 NUMERIC(20,2),
+        realisasi2 NUMERIC(20,2),
+        volume DOUBLE PRECISION,
+        fisik0 DOUBLE PRECISION,
+        fisik1 DOUBLE PRECISION,
+        fisik2 DOUBLE PRECISION,
+        UNIQUE (tahun, kd_desa, kegiatan_id, no_id)
+    )`,
+}
+
+const (
+	// Setiap upsert dimensi memakai DO UPDATE agar RETURNING selalu
+	// mengembalikan id, baik untuk baris baru maupun yang sudah ada.
+	upsertDimKegiatanQuery = `INSERT INTO dim_kegiatan (id_keg, nama_kegiatan) VALUES ($1, $2)
+        ON CONFLICT (id_keg) DO UPDATE SET nama_kegiatan = EXCLUDED.nama_kegiatan
+        RETURNING id`
+	upsertDimSumberDanaQuery = `INSERT INTO dim_sumber_dana (kode_sumber) VALUES ($1)
+        ON CONFLICT (kode_sumber) DO UPDATE SET kode_sumber = EXCLUDED.kode_sumber
+        RETURNING id`
+	upsertDimOutputQuery = `INSERT INTO dim_output (kode_output, satuan, uraian_output) VALUES ($1, $2, $3)
+        ON CONFLICT (kode_output, satuan) DO UPDATE SET uraian_output = EXCLUDED.uraian_output
+        RETURNING id`
+	upsertDimPPTKDQuery = `INSERT INTO dim_pptkd (nip, nama, jabatan) VALUES ($1, $2, $3)
+        ON CONFLICT (nip, nama, jabatan) DO UPDATE SET nip = EXCLUDED.nip
+        RETURNING id`
+
+	upsertFactOutputDetailQuery = `INSERT INTO fact_output_detail (
+            tahun, kd_desa, kegiatan_id, sumber_dana_id, output_id, pptkd_id, no_id, nama_paket,
+            pagu, nilai, anggaran1, anggaran2, realisasi0, realisasi1, realisasi2,
+            volume, fisik0, fisik1, fisik2
+        ) VALUES (
+            $1, $2, $3, $4, $5, $6, $7, $8,
+            $9, $10, $11, $12, $13, $14, $15,
+            $16, $17, $18, $19
+        )
+        ON CONFLICT (tahun, kd_desa, kegiatan_id, no_id) DO UPDATE SET
+            sumber_dana_id = EXCLUDED.sumber_dana_id,
+            output_id = EXCLUDED.output_id,
+            pptkd_id = EXCLUDED.pptkd_id,
+            nama_paket = EXCLUDED.nama_paket,
+            pagu = EXCLUDED.pagu,
+            nilai = EXCLUDED.nilai,
+            anggaran1 = EXCLUDED.anggaran1,
+            anggaran2 = EXCLUDED.anggaran2,
+            realisasi0 = EXCLUDED.realisasi0,
+            realisasi1 = EXCLUDED.realisasi1,
+            realisasi2 = EXCLUDED.realisasi2,
+            volume = EXCLUDED.volume,
+            fisik0 = EXCLUDED.fisik0,
+            fisik1 = EXCLUDED.fisik1,
+            fisik2 = EXCLUDED.fisik2`
+)
+
+// dimKeyCache menyimpan id surrogate yang sudah didapat dalam satu batch
+// agar dimensi yang sama tidak di-upsert berulang kali.
+type dimKeyCache map[string]int64
+
+// lookup mengembalikan id dari cache, atau menjalankan query upsert dan
+// menyimpan hasilnya.
+func (c dimKeyCache) lookup(ctx context.Context, tx *sqlx.Tx, key, query string, args ...interface{}) (int64, error) {
+	if id, ok := c[key]; ok {
+		return id, nil
+	}
+	var id int64
+	if err := tx.QueryRowxContext(ctx, query, args...).Scan(&id); err != nil {
+		return 0, err
+	}
+	c[key] = id
+	return id, nil
+}
+
+// upsertStarSchema menulis satu baris fakta per record beserta dimensinya,
+// di dalam transaksi yang sama dengan upsert tabel datar.
+func (s *dbStorer) upsertStarSchema(ctx context.Context, tx *sqlx.Tx, details []domain.OutputDetail) error {
+	kegiatan, sumberDana := dimKeyCache{}, dimKeyCache{}
+	output, pptkd := dimKeyCache{}, dimKeyCache{}
+
+	for _, d := range details {
+		kegiatanID, err := kegiatan.lookup(ctx, tx, d.IDKegiatan, upsertDimKegiatanQuery, d.IDKegiatan, d.NamaKegiatan)
+		if err != nil {
+			return s.dbError("upsert_dim_kegiatan", err)
+		}
+
+		// Dimensi opsional bernilai NULL jika kodenya kosong.
+		var sumberDanaID, outputID *int64
+		if d.KodeSumber != "" {
+			id, err := sumberDana.lookup(ctx, tx, d.KodeSumber, upsertDimSumberDanaQuery, d.KodeSumber)
+			if err != nil {
+				return s.dbError("upsert_dim_sumber_dana", err)
+			}
+			sumberDanaID = &id
+		}
+		if d.KodeOutput != "" {
+			id, err := output.lookup(ctx, tx, d.KodeOutput+"\x00"+d.Satuan, upsertDimOutputQuery, d.KodeOutput, d.Satuan, d.UraianOutput)
+			if err != nil {
+				return s.dbError("upsert_dim_output", err)
+			}
+			outputID = &id
+		}
+		pptkdID, err := pptkd.lookup(ctx, tx, d.NIPPPTKD+"\x00"+d.NamaPPTKD+"\x00"+d.JabatanPPTKD,
+			upsertDimPPTKDQuery, d.NIPPPTKD, d.NamaPPTKD, d.JabatanPPTKD)
+		if err != nil {
+			return s.dbError("upsert_dim_pptkd", err)
+		}
+
+		_, _, kodeDesa := dimKode(d)
+		if _, err := tx.ExecContext(ctx, upsertFactOutputDetailQuery,
+			d.Tahun, kodeDesa, kegiatanID, sumberDanaID, outputID, pptkdID, d.NoID, d.NamaPaket,
+			d.Pagu, d.Nilai, d.Anggaran1, d.Anggaran2, d.Realisasi0, d.Realisasi1, d.Realisasi2,
+			d.Volume, d.Fisik0, d.Fisik1, d.Fisik2,
+		); err != nil {
+			return s.dbError("upsert_fact_output_detail", err)
+		}
+	}
+	return nil
+}
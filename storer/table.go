@@ -0,0 +1,161 @@
+package storer
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	customErrors "github.com/aryadiwwt/synctodb/errors"
+	"github.com/aryadiwwt/synctodb/redact"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// RecordStorer menyimpan satu batch record bertipe T.
+type RecordStorer[T any] interface {
+	Store(ctx context.Context, records []T) error
+}
+
+// StoreFunc mengubah fungsi biasa menjadi RecordStorer, misalnya
+// StoreFunc[domain.OutputDetail](s.StoreOutputDetails).
+type StoreFunc[T any] func(ctx context.Context, records []T) error
+
+func (f StoreFunc[T]) Store(ctx context.Context, records []T) error {
+	return f(ctx, records)
+}
+
+// TableSpec mendeskripsikan tabel tujuan sebuah resource. BusinessKey harus
+// sama dengan unique constraint tabel karena dipakai sebagai target ON CONFLICT.
+type TableSpec struct {
+	Name        string
+	BusinessKey []string
+}
+
+// OutputDetailTable adalah tabel tujuan output detail.
+var OutputDetailTable = TableSpec{
+	Name:        "siskeudes_detail_output",
+	BusinessKey: []string{"tahun", "kd_prov", "kd_kab", "kd_kec", "kd_desa", "id_keg", "no_id"},
+}
+
+// Columns mengembalikan nama kolom dari tag db field-field T, sesuai urutan
+// deklarasi. Field tanpa tag db atau dengan tag "-" dilewati.
+func Columns[T any]() []string {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	var cols []string
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("db")
+		if name, _, _ := strings.Cut(tag, ","); name != "" && name != "-" {
+			cols = append(cols, name)
+		}
+	}
+	return cols
+}
+
+// UpsertQuery membuat query INSERT ... ON CONFLICT bernama (:kolom) untuk T.
+// Semua kolom di luar business key diperbarui jika terjadi konflik.
+func UpsertQuery[T any](spec TableSpec) string {
+	return upsertQuery(spec, Columns[T](), "")
+}
+
+// RowHashColumn adalah kolom hash per record untuk UpsertChangedQuery.
+const RowHashColumn = "row_hash"
+
+// UpsertChangedQuery seperti UpsertQuery, ditambah kolom row_hash. Baris yang
+// sudah ada hanya diperbarui jika hash-nya berbeda, sehingga record yang
+// tidak berubah tidak menghasilkan tuple baru.
+func UpsertChangedQuery[T any](spec TableSpec) string {
+	cols := append(Columns[T](), RowHashColumn)
+	where := fmt.Sprintf("%s.%s IS DISTINCT FROM EXCLUDED.%s", spec.Name, RowHashColumn, RowHashColumn)
+	return upsertQuery(spec, cols, where)
+}
+
+// upsertQuery membangun upsert untuk cols. Kolom di clear tidak ikut
+// di-insert tetapi di-set NULL saat konflik.
+func upsertQuery(spec TableSpec, cols []string, where string, clear ...string) string {
+	isKey := make(map[string]bool, len(spec.BusinessKey))
+	for _, k := range spec.BusinessKey {
+		isKey[k] = true
+	}
+
+	var params, updates []string
+	for _, c := range cols {
+		params = append(params, ":"+c)
+		if !isKey[c] {
+			updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", c, c))
+		}
+	}
+	for _, c := range clear {
+		updates = append(updates, c+" = NULL")
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s)",
+		spec.Name, strings.Join(cols, ", "), strings.Join(params, ", "), strings.Join(spec.BusinessKey, ", "))
+	if len(updates) == 0 {
+		return query + " DO NOTHING"
+	}
+	query += " DO UPDATE SET " + strings.Join(updates, ", ")
+	if where != "" {
+		query += " WHERE " + where
+	}
+	return query
+}
+
+// tableStorer menyimpan record T ke satu tabel dengan upsert generik.
+type tableStorer[T any] struct {
+	db       *sqlx.DB
+	spec     TableSpec
+	query    string
+	redactor *redact.Redactor
+}
+
+// NewTableStorer membuat RecordStorer untuk tabel spec. Kolom diambil dari tag
+// db pada T; nama tabel dan kolom divalidasi dengan ValidIdentifier.
+func NewTableStorer[T any](db *sqlx.DB, spec TableSpec, r *redact.Redactor) (RecordStorer[T], error) {
+	if len(spec.BusinessKey) == 0 {
+		return nil, fmt.Errorf("tabel %s: business key wajib diisi", spec.Name)
+	}
+	for _, ident := range append(append([]string{spec.Name}, spec.BusinessKey...), Columns[T]()...) {
+		if !ValidIdentifier(ident) {
+			return nil, fmt.Errorf("nama tabel/kolom %q tidak valid", ident)
+		}
+	}
+	return &tableStorer[T]{db: db, spec: spec, query: UpsertQuery[T](spec), redactor: r}, nil
+}
+
+func (s *tableStorer[T]) Store(ctx context.Context, records []T) error {
+	return storeInTx(ctx, s.db, s.redactor, "upsert_"+s.spec.Name, s.query, records, nil)
+}
+
+// storeInTx meng-upsert semua record di dalam satu transaksi. Fungsi after
+// (opsional) dijalankan di transaksi yang sama sebelum commit.
+func storeInTx[T any](ctx context.Context, db *sqlx.DB, r *redact.Redactor, operation, query string, records []T, after func(tx *sqlx.Tx) error) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return dbError(r, "begin_transaction", err)
+	}
+	defer tx.Rollback() // Aman untuk dipanggil meskipun sudah di-commit.
+
+	for _, rec := range records {
+		if _, err := tx.NamedExecContext(ctx, query, rec); err != nil {
+			return dbError(r, operation, err)
+		}
+	}
+
+	if after != nil {
+		if err := after(tx); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return dbError(r, "commit_transaction", err)
+	}
+	return nil
+}
+
+// dbError membungkus error database dengan konteks operasinya, dengan pesan
+// yang sudah disamarkan.
+func dbError(r *redact.Redactor, operation string, err error) error {
+	return &customErrors.ErrDBOperationFailed{Operation: operation, Err: r.Error(err)}
+}
@@ -0,0 +1,50 @@
+package storer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOutputDetailUpsertQueries(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		contains []string
+		excludes []string
+	}{
+		{
+			name:  "tanpa row_hash mengosongkan hash lama",
+			query: upsertOutputDetailQuery,
+			contains: []string{
+				"ON CONFLICT (tahun, kd_prov, kd_kab, kd_kec, kd_desa, id_keg, no_id) DO UPDATE SET",
+				"pagu = EXCLUDED.pagu",
+				"row_hash = NULL",
+			},
+			excludes: []string{":row_hash", "tahun = EXCLUDED.tahun", " WHERE "},
+		},
+		{
+			name:  "dengan row_hash hanya memperbarui baris yang berubah",
+			query: upsertChangedOutputDetailQuery,
+			contains: []string{
+				":row_hash",
+				"row_hash = EXCLUDED.row_hash",
+				"WHERE siskeudes_detail_output.row_hash IS DISTINCT FROM EXCLUDED.row_hash",
+			},
+			excludes: []string{"row_hash = NULL"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, want := range tt.contains {
+				if !strings.Contains(tt.query, want) {
+					t.Errorf("query tidak memuat %q:\n%s", want, tt.query)
+				}
+			}
+			for _, bad := range tt.excludes {
+				if strings.Contains(tt.query, bad) {
+					t.Errorf("query tidak boleh memuat %q:\n%s", bad, tt.query)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package storer
+
+import (
+	"context"
+
+	"github.com/aryadiwwt/synctodb/domain"
+	"github.com/aryadiwwt/synctodb/redact"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// TotalsReader membaca total output detail yang sudah tersimpan untuk satu
+// kabupaten/kota.
+type TotalsReader interface {
+	OutputDetailTotals(ctx context.Context, tahun string, kabupaten domain.KodeWilayah) (domain.Totals, error)
+}
+
+// Kode kabupaten dicocokkan dengan kd_kab_raw, atau kd_kab untuk baris
+// lama yang belum punya kolom raw, dalam bentuk segmen ("03"), bertitik
+// ("51.03") dan tanpa titik ("5103") agar tidak bergantung pada profil
+// transformasi.
+const outputDetailTotalsQuery = `SELECT
+        count(*) AS count,
+        COALESCE(sum(pagu), 0) AS pagu,
+        COALESCE(sum(nilai), 0) AS nilai,
+        COALESCE(sum(anggaran1), 0) AS anggaran1,
+        COALESCE(sum(anggaran2), 0) AS anggaran2,
+        COALESCE(sum(realisasi0), 0) AS realisasi0,
+        COALESCE(sum(realisasi1), 0) AS realisasi1,
+        COALESCE(sum(realisasi2), 0) AS realisasi2
+    FROM siskeudes_detail_output
+    WHERE tahun = $1 AND kd_prov = $2 AND COALESCE(kd_kab_raw, kd_kab) IN ($3, $4, $5)`
+
+type dbTotalsReader struct {
+	db       *sqlx.DB
+	redactor *redact.Redactor
+}
+
+// NewTotalsReader membuat TotalsReader dari tabel siskeudes_detail_output.
+func NewTotalsReader(db *sqlx.DB, r *redact.Redactor) TotalsReader {
+	return &dbTotalsReader{db: db, redactor: r}
+}
+
+func (s *dbTotalsReader) OutputDetailTotals(ctx context.Context, tahun string, kabupaten domain.KodeWilayah) (domain.Totals, error) {
+	var t domain.Totals
+	prov := kabupaten.Parent(domain.LevelProvinsi)
+	err := s.db.GetContext(ctx, &t, outputDetailTotalsQuery,
+		tahun, prov.Dotted(), kabupaten.Segment(), kabupaten.Dotted(), kabupaten.Compact())
+	if err != nil {
+		return domain.Totals{}, dbError(s.redactor, "select_output_detail_totals", err)
+	}
+	return t, nil
+}
@@ -0,0 +1,246 @@
+package storer
+
+import (
+	"context"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aryadiwwt/synctodb/domain"
+	"github.com/aryadiwwt/synctodb/redact"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Wilayah adalah satu kabupaten/kota yang akan disinkronkan.
+type Wilayah struct {
+	KodeProvinsi  string `db:"provinsi_id"`
+	KodeKabupaten string `db:"kota_id"`
+	NamaProvinsi  string `db:"-"`
+	NamaKabupaten string `db:"-"`
+}
+
+// Kode mengembalikan kode lengkap kabupaten/kota, misal "51.03".
+func (w Wilayah) Kode() (domain.KodeWilayah, error) {
+	prov, err := domain.ParseKodeWilayah(w.KodeProvinsi)
+	if err != nil {
+		return domain.KodeWilayah{}, err
+	}
+	return prov.Child(w.KodeKabupaten)
+}
+
+// WilayahSource menyediakan daftar kabupaten/kota yang akan diproses,
+// terurut berdasarkan kode provinsi lalu kode kabupaten.
+type WilayahSource interface {
+	// GetWilayahByProvinsi memfilter berdasarkan kode provinsi; slice kosong
+	// berarti semua provinsi.
+	GetWilayahByProvinsi(ctx context.Context, kodeProvinsi []string) ([]Wilayah, error)
+}
+
+// identifierPattern membatasi nama tabel/kolom yang bisa dikonfigurasi agar
+// tidak bisa dipakai untuk menyisipkan SQL.
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// ValidIdentifier melaporkan apakah s aman dipakai sebagai nama tabel atau
+// kolom (opsional dengan prefix schema, misal "public.master_kota").
+func ValidIdentifier(s string) bool {
+	return identifierPattern.MatchString(s)
+}
+
+// tableWilayahSource membaca wilayah dari tabel di database target,
+// secara default master_kota(provinsi_id, kota_id).
+type tableWilayahSource struct {
+	db              *sqlx.DB
+	table           string
+	provinsiColumn  string
+	kabupatenColumn string
+	redactor        *redact.Redactor
+}
+
+// NewTableWilayahSource membuat WilayahSource dari tabel database dengan nama
+// tabel dan kolom yang bisa dikonfigurasi.
+func NewTableWilayahSource(db *sqlx.DB, table, provinsiColumn, kabupatenColumn string, r *redact.Redactor) (WilayahSource, error) {
+	for _, ident := range []string{table, provinsiColumn, kabupatenColumn} {
+		if !ValidIdentifier(ident) {
+			return nil, fmt.Errorf("nama tabel/kolom wilayah %q tidak valid", ident)
+		}
+	}
+	return &tableWilayahSource{
+		db:              db,
+		table:           table,
+		provinsiColumn:  provinsiColumn,
+		kabupatenColumn: kabupatenColumn,
+		redactor:        r,
+	}, nil
+}
+
+// Implementasi fungsi untuk memfilter berdasarkan kd_prov
+func (s *tableWilayahSource) GetWilayahByProvinsi(ctx context.Context, kodeProvinsi []string) ([]Wilayah, error) {
+	var wilayah []Wilayah
+
+	// Query dasar. Kolom di-alias ke nama tetap agar cocok dengan tag db.
+	baseQuery := fmt.Sprintf(`SELECT %s AS provinsi_id, %s AS kota_id FROM %s`,
+		s.provinsiColumn, s.kabupatenColumn, s.table)
+
+	var args []interface{}
+
+	// Jika daftar provinsi diberikan, tambahkan klausa WHERE IN
+	if len(kodeProvinsi) > 0 {
+		baseQuery += fmt.Sprintf(` WHERE %s IN (?)`, s.provinsiColumn)
+		args = append(args, kodeProvinsi)
+	}
+
+	baseQuery += fmt.Sprintf(` ORDER BY %s, %s`, s.provinsiColumn, s.kabupatenColumn)
+
+	// sqlx.In secara aman akan mengubah query (?) menjadi ($1, $2, ...)
+	// dan menyesuaikan argumennya. Ini cara aman untuk klausa IN.
+	query, args, err := sqlx.In(baseQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuat query IN: %w", s.redactor.Error(err))
+	}
+
+	// Rebind query agar sesuai dengan placeholder PostgreSQL ($1, $2)
+	query = s.db.Rebind(query)
+
+	err = s.db.SelectContext(ctx, &wilayah, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil data wilayah yang difilter: %w", s.redactor.Error(err))
+	}
+
+	// Lakukan loop untuk memformat kode kabupaten setelah data didapat
+	for i := range wilayah {
+		wilayah[i].KodeKabupaten = padKode(wilayah[i].KodeKabupaten)
+	}
+
+	return wilayah, nil
+}
+
+// padKode mengembalikan segmen terakhir kode wilayah yang sudah di-pad,
+// sehingga "3", "03" dan "5103" sama-sama menjadi "03". Kode yang tidak
+// valid dibiarkan apa adanya.
+func padKode(kode string) string {
+	k, err := domain.ParseKodeWilayah(kode)
+	if err != nil {
+		log.Printf("Peringatan: Format kode wilayah '%s' tidak valid, tidak diformat: %v", kode, err)
+		return kode
+	}
+	return k.Segment()
+}
+
+// kodeWilayahCSV adalah dataset kode wilayah Kemendagri (provinsi dan
+// kabupaten/kota, Kepmendagri 100.1.1-6117 Tahun 2022) yang ikut
+// dikompilasi ke dalam binary.
+//
+//go:embed data/kode_wilayah.csv
+var kodeWilayahCSV string
+
+// listWilayahSource menyajikan wilayah dari daftar di memori, dipakai oleh
+// dataset bawaan maupun file CSV.
+type listWilayahSource struct {
+	wilayah []Wilayah
+}
+
+// NewEmbeddedWilayahSource membuat WilayahSource dari dataset kode wilayah
+// Kemendagri yang dibundel di dalam binary.
+func NewEmbeddedWilayahSource() (WilayahSource, error) {
+	wilayah, err := parseWilayahCSV(strings.NewReader(kodeWilayahCSV))
+	if err != nil {
+		return nil, fmt.Errorf("dataset wilayah bawaan rusak: %w", err)
+	}
+	return &listWilayahSource{wilayah: wilayah}, nil
+}
+
+// NewCSVWilayahSource membuat WilayahSource dari file CSV dengan header
+// kode_provinsi,kode_kabupaten dan kolom opsional nama_provinsi,nama_kabupaten.
+func NewCSVWilayahSource(path string) (WilayahSource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuka file wilayah: %w", err)
+	}
+	defer f.Close()
+
+	wilayah, err := parseWilayahCSV(f)
+	if err != nil {
+		return nil, fmt.Errorf("file wilayah %s tidak valid: %w", path, err)
+	}
+	return &listWilayahSource{wilayah: wilayah}, nil
+}
+
+func parseWilayahCSV(r io.Reader) ([]Wilayah, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca header: %w", err)
+	}
+	col := make(map[string]int)
+	for i, name := range header {
+		col[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	provIdx, okProv := col["kode_provinsi"]
+	kabIdx, okKab := col["kode_kabupaten"]
+	if !okProv || !okKab {
+		return nil, fmt.Errorf("header wajib memuat kode_provinsi dan kode_kabupaten")
+	}
+	get := func(record []string, name string) string {
+		if i, ok := col[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var wilayah []Wilayah
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if provIdx >= len(record) || kabIdx >= len(record) {
+			return nil, fmt.Errorf("baris %d: kolom kode tidak lengkap", line)
+		}
+		wilayah = append(wilayah, Wilayah{
+			KodeProvinsi:  padKode(record[provIdx]),
+			KodeKabupaten: padKode(record[kabIdx]),
+			NamaProvinsi:  get(record, "nama_provinsi"),
+			NamaKabupaten: get(record, "nama_kabupaten"),
+		})
+	}
+
+	sort.SliceStable(wilayah, func(i, j int) bool {
+		if wilayah[i].KodeProvinsi != wilayah[j].KodeProvinsi {
+			return wilayah[i].KodeProvinsi < wilayah[j].KodeProvinsi
+		}
+		return wilayah[i].KodeKabupaten < wilayah[j].KodeKabupaten
+	})
+	return wilayah, nil
+}
+
+func (s *listWilayahSource) GetWilayahByProvinsi(_ context.Context, kodeProvinsi []string) ([]Wilayah, error) {
+	if len(kodeProvinsi) == 0 {
+		return append([]Wilayah(nil), s.wilayah...), nil
+	}
+
+	wanted := make(map[string]bool, len(kodeProvinsi))
+	for _, kode := range kodeProvinsi {
+		wanted[padKode(kode)] = true
+	}
+
+	var wilayah []Wilayah
+	for _, w := range s.wilayah {
+		if wanted[w.KodeProvinsi] {
+			wilayah = append(wilayah, w)
+		}
+	}
+	return wilayah, nil
+}
@@ -0,0 +1,299 @@
+package synchronizer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	customErrors "github.com/aryadiwwt/synctodb/errors"
+	"github.com/aryadiwwt/synctodb/fetcher"
+	"github.com/aryadiwwt/synctodb/redact"
+	"github.com/aryadiwwt/synctodb/storer"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Engine menjalankan alur fetch, transform dan store satu Resource untuk
+// setiap kabupaten/kota dari WilayahSource.
+type Engine[T any] struct {
+	resource Resource[T]
+	fetcher  fetcher.RecordFetcher
+	storer   storer.RecordStorer[T]
+	wilayah  storer.WilayahSource
+	log      *log.Logger
+	delay    time.Duration // jeda antar kabupaten
+
+	afterStore []AfterStoreFunc
+	hashes     storer.ContentHashStore
+	hashTahun  string // tahun anggaran yang ikut menjadi kunci content hash
+	// wilayahTimeout membatasi fetch, transform dan store satu kabupaten;
+	// 0 berarti hanya dibatasi context run.
+	wilayahTimeout time.Duration
+	progress       []func(storer.Wilayah)
+}
+
+// AfterStoreFunc dipanggil setelah data satu kabupaten/kota berhasil
+// disimpan, misal untuk me-refresh tabel agregat wilayah tersebut.
+type AfterStoreFunc func(ctx context.Context, w storer.Wilayah) error
+
+// EngineOption mengatur perilaku opsional Engine.
+type EngineOption func(*engineOptions)
+
+type engineOptions struct {
+	afterStore     []AfterStoreFunc
+	hashes         storer.ContentHashStore
+	hashTahun      string
+	wilayahTimeout time.Duration
+	progress       []func(storer.Wilayah)
+}
+
+// WithAfterStore menambahkan hook yang dijalankan setelah setiap kabupaten
+// tersimpan. Error dari hook dicatat tanpa menghentikan sinkronisasi karena
+// datanya sendiri sudah tersimpan, tetapi content hash kabupaten itu tidak
+// disimpan agar run berikutnya menjalankan hook lagi.
+func WithAfterStore(fn AfterStoreFunc) EngineOption {
+	return func(o *engineOptions) {
+		o.afterStore = append(o.afterStore, fn)
+	}
+}
+
+// WithContentHash melewati penyimpanan (dan hook AfterStore) untuk
+// kabupaten yang content hash-nya sama dengan sinkronisasi terakhir tahun
+// anggaran yang sama.
+func WithContentHash(store storer.ContentHashStore, tahun string) EngineOption {
+	return func(o *engineOptions) {
+		o.hashes = store
+		o.hashTahun = tahun
+	}
+}
+
+// WithWilayahTimeout membatasi waktu pemrosesan satu kabupaten. Kabupaten
+// yang melewatinya dicatat sebagai error dan run berlanjut.
+func WithWilayahTimeout(d time.Duration) EngineOption {
+	return func(o *engineOptions) { o.wilayahTimeout = d }
+}
+
+// WithProgress memanggil fn setiap kali satu kabupaten selesai diproses,
+// termasuk yang kosong, tidak berubah, atau gagal lalu dilewati. Kabupaten
+// yang terhenti karena run dibatalkan tidak dilaporkan.
+func WithProgress(fn func(storer.Wilayah)) EngineOption {
+	return func(o *engineOptions) { o.progress = append(o.progress, fn) }
+}
+
+func NewEngine[T any](r Resource[T], f fetcher.RecordFetcher, s storer.RecordStorer[T], w storer.WilayahSource, l *log.Logger, delay time.Duration, opts ...EngineOption) *Engine[T] {
+	var o engineOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Engine[T]{
+		resource:   r,
+		fetcher:    f,
+		storer:     s,
+		wilayah:    w,
+		log:        l,
+		delay:      delay,
+		afterStore: o.afterStore,
+		hashes:     o.hashes,
+		hashTahun:  o.hashTahun,
+
+		wilayahTimeout: o.wilayahTimeout,
+		progress:       o.progress,
+	}
+}
+
+// NewTableEngine membuat Engine yang menyimpan ke r.Table lewat
+// storer.NewTableStorer, sehingga resource baru cukup dideklarasikan dengan
+// Table-nya tanpa storer khusus.
+func NewTableEngine[T any](r Resource[T], f fetcher.RecordFetcher, db *sqlx.DB, redactor *redact.Redactor, w storer.WilayahSource, l *log.Logger, delay time.Duration, opts ...EngineOption) (*Engine[T], error) {
+	s, err := storer.NewTableStorer[T](db, r.Table, redactor)
+	if err != nil {
+		return nil, fmt.Errorf("resource %s: %w", r.Name, err)
+	}
+	return NewEngine(r, f, s, w, l, delay, opts...), nil
+}
+
+func (e *Engine[T]) Name() string {
+	return e.resource.Name
+}
+
+// Synchronize memproses semua kabupaten/kota di provinsi yang diminta,
+// dimulai dari startKabupaten jika diisi.
+func (e *Engine[T]) Synchronize(ctx context.Context, kodeProvinsi []string, startKabupaten string) error {
+	e.log.Printf("Starting %s synchronization...", e.resource.Name)
+
+	daftarWilayah, err := e.wilayah.GetWilayahByProvinsi(ctx, kodeProvinsi)
+	if err != nil {
+		return fmt.Errorf("gagal mendapatkan daftar wilayah: %w", err)
+	}
+
+	if len(daftarWilayah) == 0 {
+		e.log.Println("Tidak ada data wilayah yang ditemukan untuk diproses. Selesai.")
+		return nil
+	}
+
+	e.log.Printf("Akan memproses data untuk %d kabupaten/kota...", len(daftarWilayah))
+	// 'startProcessing' akan menjadi 'true' setelah kita menemukan kabupaten awal
+	// Jika tidak ada flag -kab, langsung set ke true.
+	startProcessing := (startKabupaten == "")
+
+	for _, wilayah := range daftarWilayah {
+		// Jika kita belum sampai ke titik awal, cek apakah ini titik awalnya
+		if !startProcessing {
+			// Jika kode kabupaten saat ini cocok dengan flag, mulai proses dari sini
+			if wilayah.KodeKabupaten == startKabupaten {
+				e.log.Printf("Titik awal ditemukan. Memulai proses dari Kabupaten: %s", startKabupaten)
+				startProcessing = true
+			} else {
+				// Jika tidak cocok, lewati kabupaten ini
+				e.log.Printf("Melewati Kabupaten: %s (sebelum titik awal)", wilayah.KodeKabupaten)
+				continue
+			}
+		}
+		// Run yang dibatalkan atau melewati batas waktunya tidak perlu
+		// mencoba kabupaten berikutnya.
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("sinkronisasi %s dihentikan sebelum Prov %s Kab %s: %w", e.resource.Name, wilayah.KodeProvinsi, wilayah.KodeKabupaten, err)
+		}
+
+		// Proses sinkronisasi hanya berjalan jika startProcessing sudah true
+		stored, err := e.processWilayah(ctx, wilayah)
+		if err != nil {
+			return err
+		}
+		for _, fn := range e.progress {
+			fn(wilayah)
+		}
+		if !stored {
+			continue
+		}
+
+		// Opsional: jeda tetap antar kabupaten. Laju request halaman sudah
+		// diatur rate limiter fetcher, jadi defaultnya 0.
+		if e.delay > 0 {
+			e.log.Printf("Memberi jeda %s...", e.delay)
+			if err := sleepCtx(ctx, e.delay); err != nil {
+				return err
+			}
+		}
+	}
+
+	e.log.Printf("Semua proses sinkronisasi %s untuk seluruh wilayah telah selesai.", e.resource.Name)
+	return nil
+}
+
+// processWilayah menjalankan fetch, transform dan store satu kabupaten dengan
+// batas waktu wilayahTimeout. Error per wilayah hanya dicatat dan stored
+// bernilai false; error yang dikembalikan menghentikan seluruh run.
+func (e *Engine[T]) processWilayah(ctx context.Context, wilayah storer.Wilayah) (stored bool, err error) {
+	wctx := ctx
+	if e.wilayahTimeout > 0 {
+		var cancel context.CancelFunc
+		wctx, cancel = context.WithTimeout(ctx, e.wilayahTimeout)
+		defer cancel()
+	}
+
+	e.log.Printf("=== [%s] Memproses Provinsi: %s, Kabupaten: %s ===", e.resource.Name, wilayah.KodeProvinsi, wilayah.KodeKabupaten)
+	records, err := fetcher.Fetch[T](wctx, e.fetcher, e.resource.Endpoint, wilayah.KodeProvinsi, wilayah.KodeKabupaten)
+	if err != nil {
+		// API dinyatakan tidak tersedia oleh circuit breaker: tidak ada
+		// gunanya mencoba kabupaten berikutnya.
+		var unavailable *customErrors.ErrUpstreamUnavailable
+		if errors.As(err, &unavailable) || ctx.Err() != nil {
+			return false, fmt.Errorf("sinkronisasi %s dihentikan di Prov %s Kab %s: %w", e.resource.Name, wilayah.KodeProvinsi, wilayah.KodeKabupaten, err)
+		}
+		e.log.Printf("ERROR saat mengambil data untuk Prov %s Kab %s: %v. Melanjutkan ke wilayah berikutnya.", wilayah.KodeProvinsi, wilayah.KodeKabupaten, e.wilayahError(wctx, err))
+		return false, nil // Lanjut ke wilayah berikutnya jika ada error
+	}
+
+	if len(records) == 0 {
+		e.log.Println("Tidak ada data untuk wilayah ini.")
+		return false, nil
+	}
+
+	// Transformasi data (jika ada)
+	e.log.Println("Transforming data...")
+	records = e.resource.transform(records)
+	e.log.Println("Data transformation complete.")
+
+	// Lewati kabupaten yang datanya sama persis dengan run sebelumnya.
+	hashKey, hash, unchanged := e.unchanged(wctx, wilayah, records)
+	if unchanged {
+		e.log.Printf("=== Data Provinsi: %s, Kabupaten: %s tidak berubah sejak sinkronisasi terakhir (%d data). Dilewati. ===", wilayah.KodeProvinsi, wilayah.KodeKabupaten, len(records))
+		return false, nil
+	}
+
+	// Simpan data ke database (menggunakan batch processing)
+	if err := e.storer.Store(wctx, records); err != nil {
+		if ctx.Err() != nil {
+			return false, fmt.Errorf("sinkronisasi %s dihentikan saat menyimpan Prov %s Kab %s: %w", e.resource.Name, wilayah.KodeProvinsi, wilayah.KodeKabupaten, err)
+		}
+		e.log.Printf("ERROR saat menyimpan data untuk Prov %s Kab %s: %v", wilayah.KodeProvinsi, wilayah.KodeKabupaten, e.wilayahError(wctx, err))
+		return false, nil
+	}
+
+	e.log.Printf("=== Selesai memproses untuk Provinsi: %s, Kabupaten: %s. Total %d data disimpan. ===", wilayah.KodeProvinsi, wilayah.KodeKabupaten, len(records))
+
+	afterStoreOK := true
+	for _, fn := range e.afterStore {
+		if err := fn(wctx, wilayah); err != nil {
+			afterStoreOK = false
+			e.log.Printf("ERROR setelah menyimpan Prov %s Kab %s: %v", wilayah.KodeProvinsi, wilayah.KodeKabupaten, err)
+		}
+	}
+	// Hash hanya disimpan jika semua hook berhasil; jika tidak, run berikutnya
+	// tidak melewati kabupaten ini sehingga hook (misal refresh agregat)
+	// dicoba lagi.
+	if hash != "" && afterStoreOK {
+		if err := e.hashes.SaveContentHash(wctx, e.resource.Name, e.hashTahun, hashKey, hash, len(records)); err != nil {
+			e.log.Printf("WARNING: gagal menyimpan content hash Prov %s Kab %s: %v", wilayah.KodeProvinsi, wilayah.KodeKabupaten, err)
+		}
+	}
+	return true, nil
+}
+
+// wilayahError memperjelas error yang terjadi karena batas waktu wilayah.
+func (e *Engine[T]) wilayahError(wctx context.Context, err error) error {
+	if errors.Is(wctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("melewati batas waktu wilayah %s: %w", e.wilayahTimeout, err)
+	}
+	return err
+}
+
+// unchanged menghitung content hash records dan membandingkannya dengan hash
+// tersimpan. hash kosong berarti content hash tidak aktif atau gagal dihitung;
+// kegagalan hanya dicatat dan data tetap disimpan.
+func (e *Engine[T]) unchanged(ctx context.Context, w storer.Wilayah, records []T) (key, hash string, unchanged bool) {
+	if e.hashes == nil {
+		return "", "", false
+	}
+	key = w.KodeProvinsi + "." + w.KodeKabupaten
+	if kode, err := w.Kode(); err == nil {
+		key = kode.Dotted()
+	}
+
+	hash, err := storer.ContentHash(records)
+	if err != nil {
+		e.log.Printf("WARNING: gagal menghitung content hash %s: %v", key, err)
+		return key, "", false
+	}
+	previous, err := e.hashes.ContentHash(ctx, e.resource.Name, e.hashTahun, key)
+	if err != nil {
+		e.log.Printf("WARNING: gagal membaca content hash %s: %v", key, err)
+		return key, hash, false
+	}
+	return key, hash, previous == hash
+}
+
+// sleepCtx menunggu selama d atau sampai ctx selesai.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
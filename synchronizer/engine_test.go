@@ -0,0 +1,84 @@
+package synchronizer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"testing"
+
+	"github.com/aryadiwwt/synctodb/storer"
+)
+
+type testRecord struct {
+	ID    string `json:"id"`
+	Nilai int    `json:"nilai"`
+}
+
+type staticFetcher []json.RawMessage
+
+func (f staticFetcher) FetchRecords(context.Context, string, string, string) ([]json.RawMessage, error) {
+	return f, nil
+}
+
+type staticWilayah []storer.Wilayah
+
+func (w staticWilayah) GetWilayahByProvinsi(context.Context, []string) ([]storer.Wilayah, error) {
+	return w, nil
+}
+
+// memoryHashes adalah ContentHashStore di memori.
+type memoryHashes map[string]string
+
+func (m memoryHashes) ContentHash(_ context.Context, resource, tahun, kabupaten string) (string, error) {
+	return m[resource+"|"+tahun+"|"+kabupaten], nil
+}
+
+func (m memoryHashes) SaveContentHash(_ context.Context, resource, tahun, kabupaten, hash string, _ int) error {
+	m[resource+"|"+tahun+"|"+kabupaten] = hash
+	return nil
+}
+
+func TestEngineContentHashAfterStore(t *testing.T) {
+	tests := []struct {
+		name       string
+		hookErr    error
+		wantStores int // jumlah Store setelah dua run
+		wantHooks  int
+	}{
+		{name: "hook berhasil, run kedua dilewati", wantStores: 1, wantHooks: 1},
+		{name: "hook gagal, run kedua mencoba lagi", hookErr: errors.New("refresh agregat gagal"), wantStores: 2, wantHooks: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stores, hooks := 0, 0
+			store := storer.StoreFunc[testRecord](func(context.Context, []testRecord) error {
+				stores++
+				return nil
+			})
+			hook := func(context.Context, storer.Wilayah) error {
+				hooks++
+				return tt.hookErr
+			}
+			e := NewEngine(
+				Resource[testRecord]{Name: "test"},
+				staticFetcher{json.RawMessage(`{"id":"a","nilai":1}`), json.RawMessage(`{"id":"b","nilai":2}`)},
+				store,
+				staticWilayah{{KodeProvinsi: "51", KodeKabupaten: "03"}},
+				log.New(io.Discard, "", 0),
+				0,
+				WithAfterStore(hook),
+				WithContentHash(memoryHashes{}, "2024"),
+			)
+			for run := 0; run < 2; run++ {
+				if err := e.Synchronize(context.Background(), nil, ""); err != nil {
+					t.Fatalf("run %d: Synchronize() error = %v", run+1, err)
+				}
+			}
+			if stores != tt.wantStores || hooks != tt.wantHooks {
+				t.Errorf("Store %d kali, hook %d kali; want %d dan %d", stores, hooks, tt.wantStores, tt.wantHooks)
+			}
+		})
+	}
+}
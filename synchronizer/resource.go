@@ -0,0 +1,77 @@
+package synchronizer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aryadiwwt/synctodb/storer"
+)
+
+// Transform mengubah satu batch record sebelum disimpan.
+type Transform[T any] func(records []T) []T
+
+// Resource mendeskripsikan satu endpoint rekap konsolidasi-apbdesa: dari mana
+// data diambil, tipe domainnya (T), ke tabel mana disimpan dan transformasi
+// yang dijalankan di antaranya.
+type Resource[T any] struct {
+	// Name adalah nama unik resource, dipakai di log dan sync.resources.
+	Name string
+	// Endpoint adalah URL halaman pertama; halaman berikutnya mengikuti
+	// next_page_url dari respons.
+	Endpoint string
+	// Table adalah tabel tujuan beserta business key-nya. NewTableEngine
+	// menyimpan ke tabel ini dengan upsert yang dibangun dari tag db T.
+	// Resource dengan storer sendiri (NewEngine) mengosongkannya.
+	Table storer.TableSpec
+	// Transforms dijalankan berurutan pada setiap batch.
+	Transforms []Transform[T]
+}
+
+func (r Resource[T]) transform(records []T) []T {
+	for _, t := range r.Transforms {
+		records = t(records)
+	}
+	return records
+}
+
+// Runner adalah Engine yang tipe domainnya sudah disembunyikan, sehingga
+// resource dengan tipe berbeda bisa disimpan di satu Registry.
+type Runner interface {
+	Name() string
+	Synchronize(ctx context.Context, kodeProvinsi []string, startKabupaten string) error
+}
+
+// Registry menyimpan Runner berdasarkan nama resource.
+type Registry struct {
+	runners map[string]Runner
+}
+
+func NewRegistry() *Registry {
+	return &Registry{runners: make(map[string]Runner)}
+}
+
+// Register menambahkan runner; nama yang sama tidak boleh didaftarkan dua kali.
+func (r *Registry) Register(run Runner) error {
+	if _, exists := r.runners[run.Name()]; exists {
+		return fmt.Errorf("resource %q sudah terdaftar", run.Name())
+	}
+	r.runners[run.Name()] = run
+	return nil
+}
+
+// Get mengembalikan runner untuk nama resource.
+func (r *Registry) Get(name string) (Runner, bool) {
+	run, ok := r.runners[name]
+	return run, ok
+}
+
+// Names mengembalikan nama semua resource terdaftar, terurut.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.runners))
+	for name := range r.runners {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,135 @@
+package synchronizer
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/aryadiwwt/synctodb/storer"
+)
+
+// Status run di RunState.
+const (
+	RunRunning     = "running"
+	RunCompleted   = "completed"
+	RunInterrupted = "interrupted"
+	RunFailed      = "failed"
+)
+
+// WilayahRef menunjuk satu kabupaten/kota di RunState.
+type WilayahRef struct {
+	KodeProvinsi  string `json:"kd_prov"`
+	KodeKabupaten string `json:"kd_kab"`
+}
+
+// RunState adalah status run terakhir yang disimpan sebagai JSON. Status
+// "running" yang tertinggal berarti proses mati tanpa sempat mencatat
+// apa pun (misal SIGKILL).
+type RunState struct {
+	Status     string     `json:"status"`
+	Resource   string     `json:"resource,omitempty"`
+	Provinsi   []string   `json:"provinsi,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	// LastCompleted adalah kabupaten terakhir yang selesai diproses (berhasil,
+	// kosong, tidak berubah, atau gagal lalu dilewati).
+	LastCompleted *WilayahRef `json:"last_completed,omitempty"`
+	Error         string      `json:"error,omitempty"`
+}
+
+// RunRecorder menulis RunState ke file setiap kali berubah. Path kosong
+// menonaktifkan penulisan.
+type RunRecorder struct {
+	mu    sync.Mutex
+	path  string
+	state RunState
+	log   *log.Logger
+}
+
+// NewRunRecorder membuat recorder dengan status running. File baru ditulis
+// pada perubahan pertama (Start).
+func NewRunRecorder(path string, provinsi []string, l *log.Logger) *RunRecorder {
+	return &RunRecorder{
+		path:  path,
+		state: RunState{Status: RunRunning, Provinsi: provinsi, StartedAt: time.Now().UTC()},
+		log:   l,
+	}
+}
+
+// Start mencatat resource yang sedang disinkronkan.
+func (r *RunRecorder) Start(resource string) {
+	r.update(func(s *RunState) {
+		s.Resource = resource
+		s.LastCompleted = nil
+	})
+}
+
+// Completed mencatat kabupaten yang baru selesai diproses. Cocok dipakai
+// dengan WithProgress.
+func (r *RunRecorder) Completed(w storer.Wilayah) {
+	r.update(func(s *RunState) {
+		s.LastCompleted = &WilayahRef{KodeProvinsi: w.KodeProvinsi, KodeKabupaten: w.KodeKabupaten}
+	})
+}
+
+// Finish mencatat status akhir run.
+func (r *RunRecorder) Finish(status string, err error) {
+	r.update(func(s *RunState) {
+		now := time.Now().UTC()
+		s.Status, s.FinishedAt = status, &now
+		if err != nil {
+			s.Error = err.Error()
+		}
+	})
+}
+
+// State mengembalikan salinan state saat ini.
+func (r *RunRecorder) State() RunState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state
+}
+
+func (r *RunRecorder) update(fn func(*RunState)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fn(&r.state)
+	if r.path == "" {
+		return
+	}
+	if err := writeRunState(r.path, r.state); err != nil {
+		r.log.Printf("WARNING: gagal menulis state run ke %s: %v", r.path, err)
+	}
+}
+
+// writeRunState menulis ke file sementara lalu me-rename-nya, sehingga file
+// state tidak pernah setengah tertulis meskipun proses dihentikan.
+func writeRunState(path string, state RunState) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // tidak berpengaruh setelah rename berhasil
+
+	enc := json.NewEncoder(tmp)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(state); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
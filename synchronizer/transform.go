@@ -0,0 +1,262 @@
+package synchronizer
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/aryadiwwt/synctodb/domain"
+)
+
+// StepFactory membuat Transform untuk satu step rantai transformasi. Step
+// ditulis di konfigurasi sebagai "nama" atau "nama:argumen" (misal "trim"
+// atau "case:upper"); arg adalah bagian setelah ":", dan mappings adalah isi
+// transform.mappings yang dipakai step "map".
+type StepFactory[T any] func(arg string, mappings map[string]string) (Transform[T], error)
+
+// DefaultProfile adalah profil transformasi yang dipakai jika konfigurasi
+// tidak memilih profil lain.
+const DefaultProfile = "default"
+
+// OutputDetailProfiles adalah rantai step bawaan untuk output detail.
+// Profil "default" menghasilkan kode lengkap bertitik (kd_desa "51.03.03.2001").
+var OutputDetailProfiles = map[string][]string{
+	DefaultProfile: {"codes:dotted"},
+	"raw":          {"codes:raw", "trim"},
+	"compact":      {"trim", "codes:compact"},
+}
+
+// outputDetailSteps adalah step khusus output detail, di samping step
+// generik dari genericSteps. Peringatan step ditulis ke l.
+func outputDetailSteps(l *log.Logger) map[string]StepFactory[domain.OutputDetail] {
+	return map[string]StepFactory[domain.OutputDetail]{
+		"codes": codesStep(l),
+	}
+}
+
+// OutputDetailTransforms membangun rantai transformasi output detail dari
+// profil, atau dari steps jika diisi (steps menggantikan profil). Peringatan
+// saat transformasi, misal kode wilayah yang tidak valid, ditulis ke l.
+func OutputDetailTransforms(profile string, steps []string, mappings map[string]string, l *log.Logger) ([]Transform[domain.OutputDetail], error) {
+	if len(steps) == 0 {
+		var ok bool
+		if steps, ok = OutputDetailProfiles[profile]; !ok {
+			return nil, fmt.Errorf("profil transformasi %q tidak dikenal (tersedia: %s)", profile, strings.Join(profileNames(), ", "))
+		}
+	}
+	return BuildTransforms(steps, mappings, outputDetailSteps(l))
+}
+
+func profileNames() []string {
+	names := make([]string, 0, len(OutputDetailProfiles))
+	for name := range OutputDetailProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BuildTransforms menerjemahkan daftar step menjadi Transform berurutan.
+// Step di extra melengkapi (atau menimpa) step generik.
+func BuildTransforms[T any](steps []string, mappings map[string]string, extra map[string]StepFactory[T]) ([]Transform[T], error) {
+	factories := genericSteps[T]()
+	for name, f := range extra {
+		factories[name] = f
+	}
+
+	transforms := make([]Transform[T], 0, len(steps))
+	for _, step := range steps {
+		name, arg, _ := strings.Cut(strings.TrimSpace(step), ":")
+		factory, ok := factories[name]
+		if !ok {
+			return nil, fmt.Errorf("step transformasi %q tidak dikenal", step)
+		}
+		t, err := factory(arg, mappings)
+		if err != nil {
+			return nil, fmt.Errorf("step transformasi %q: %w", step, err)
+		}
+		transforms = append(transforms, t)
+	}
+	return transforms, nil
+}
+
+// genericSteps berlaku untuk tipe domain apa pun karena bekerja lewat tag db.
+func genericSteps[T any]() map[string]StepFactory[T] {
+	return map[string]StepFactory[T]{
+		"trim": trimStep[T],
+		"case": caseStep[T],
+		"map":  mapStep[T],
+	}
+}
+
+// trimStep membuang spasi di awal dan akhir semua field string kecuali
+// kolom *_raw.
+func trimStep[T any](arg string, _ map[string]string) (Transform[T], error) {
+	if arg != "" {
+		return nil, fmt.Errorf("tidak menerima argumen")
+	}
+	return eachString[T](func(_ string, v string) string { return strings.TrimSpace(v) }), nil
+}
+
+// caseStep mengubah huruf pada field nama (kolom berawalan "nama"):
+// upper, lower atau title.
+func caseStep[T any](arg string, _ map[string]string) (Transform[T], error) {
+	var fn func(string) string
+	switch arg {
+	case "upper":
+		fn = strings.ToUpper
+	case "lower":
+		fn = strings.ToLower
+	case "title":
+		fn = titleCase
+	default:
+		return nil, fmt.Errorf("argumen harus upper, lower atau title")
+	}
+	return eachString[T](func(column, v string) string {
+		if strings.HasPrefix(column, "nama") {
+			return fn(v)
+		}
+		return v
+	}), nil
+}
+
+// mapStep mengganti nilai satu kolom memakai transform.mappings dengan key
+// "<kolom>.<nilai lama>", misal "kode_sumber.DDS: DD". Nilai yang tidak ada
+// di mapping dibiarkan.
+func mapStep[T any](column string, mappings map[string]string) (Transform[T], error) {
+	if column == "" {
+		return nil, fmt.Errorf("nama kolom wajib diisi, misal map:kode_sumber")
+	}
+	if !hasStringColumn[T](column) {
+		return nil, fmt.Errorf("kolom string %q tidak ada", column)
+	}
+	table := make(map[string]string)
+	for key, to := range mappings {
+		if col, from, ok := strings.Cut(key, "."); ok && col == column {
+			table[from] = to
+		}
+	}
+	if len(table) == 0 {
+		return nil, fmt.Errorf("tidak ada transform.mappings untuk kolom %q", column)
+	}
+	return eachString[T](func(c, v string) string {
+		if to, ok := table[v]; ok && c == column {
+			return to
+		}
+		return v
+	}), nil
+}
+
+// eachString menerapkan fn ke setiap field string bertag db pada setiap
+// record. Kolom *_raw menyimpan nilai asli API dan tidak pernah diubah.
+func eachString[T any](fn func(column, value string) string) Transform[T] {
+	return func(records []T) []T {
+		for i := range records {
+			v := reflect.ValueOf(&records[i]).Elem()
+			if v.Kind() != reflect.Struct {
+				continue
+			}
+			for j := 0; j < v.NumField(); j++ {
+				column := dbColumn(v.Type().Field(j))
+				if column == "" || strings.HasSuffix(column, "_raw") || v.Field(j).Kind() != reflect.String {
+					continue
+				}
+				v.Field(j).SetString(fn(column, v.Field(j).String()))
+			}
+		}
+		return records
+	}
+}
+
+func hasStringColumn[T any](column string) bool {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if dbColumn(t.Field(i)) == column && t.Field(i).Type.Kind() == reflect.String {
+			return true
+		}
+	}
+	return false
+}
+
+func dbColumn(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("db"), ",")
+	if name == "-" || !f.IsExported() {
+		return ""
+	}
+	return name
+}
+
+// titleCase mengubah "KABUPATEN BADUNG" menjadi "Kabupaten Badung". Huruf
+// pertama diambil per rune agar nama dengan huruf multi-byte tetap UTF-8
+// yang valid.
+func titleCase(s string) string {
+	words := strings.Fields(strings.ToLower(s))
+	for i, w := range words {
+		r, size := utf8.DecodeRuneInString(w)
+		words[i] = string(unicode.ToUpper(r)) + w[size:]
+	}
+	return strings.Join(words, " ")
+}
+
+// codesStep memformat kd_kab, kd_kec dan kd_desa. Kode selalu dihitung
+// dari kolom *_raw jika terisi, sehingga step ini idempoten dan nilai asli
+// API tetap tersimpan:
+//
+//   - dotted: kode lengkap bertitik, misal kd_desa "51.03.03.2001"
+//   - raw: kode seperti dari API
+//   - compact: kode Kemendagri lengkap tanpa titik, 10 digit untuk desa
+//     ("5103032001"). Ini bukan kode BPS.
+func codesStep(l *log.Logger) StepFactory[domain.OutputDetail] {
+	return func(arg string, _ map[string]string) (Transform[domain.OutputDetail], error) {
+		switch arg {
+		case "dotted":
+			return qualifyCodes(".", l), nil
+		case "compact":
+			return qualifyCodes("", l), nil
+		case "raw":
+			return rawCodes, nil
+		default:
+			return nil, fmt.Errorf("argumen harus dotted, raw atau compact")
+		}
+	}
+}
+
+// rawCodes mengembalikan kd_kab, kd_kec dan kd_desa ke nilai asli API.
+func rawCodes(details []domain.OutputDetail) []domain.OutputDetail {
+	for i := range details {
+		d := &details[i]
+		d.KodeKabupaten = domain.FirstNonEmpty(d.KodeKabupatenRaw, d.KodeKabupaten)
+		d.KodeKecamatan = domain.FirstNonEmpty(d.KodeKecamatanRaw, d.KodeKecamatan)
+		d.KodeDesa = domain.FirstNonEmpty(d.KodeDesaRaw, d.KodeDesa)
+	}
+	return details
+}
+
+// qualifyCodes menggabungkan kode induk ke kd_kab, kd_kec dan kd_desa
+// dengan pemisah sep. Record dengan kode yang tidak valid dibiarkan apa
+// adanya dan dicatat di l (log.Default() jika nil).
+func qualifyCodes(sep string, l *log.Logger) Transform[domain.OutputDetail] {
+	if l == nil {
+		l = log.Default()
+	}
+	return func(details []domain.OutputDetail) []domain.OutputDetail {
+		for i := range details {
+			kab, kec, desa, err := details[i].QualifiedKode()
+			if err != nil {
+				l.Printf("WARNING: kode wilayah id_keg=%s no_id=%s tidak diformat: %v", details[i].IDKegiatan, details[i].NoID, err)
+				continue
+			}
+			details[i].KodeKabupaten = kab.Format(sep)
+			details[i].KodeKecamatan = kec.Format(sep)
+			details[i].KodeDesa = desa.Format(sep)
+		}
+		return details
+	}
+}
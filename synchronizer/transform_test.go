@@ -0,0 +1,196 @@
+package synchronizer
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/aryadiwwt/synctodb/domain"
+)
+
+// sampleDetail adalah record seperti hasil decode payload API: kode relatif
+// dan *_raw sama dengan nilai aslinya.
+func sampleDetail() domain.OutputDetail {
+	return domain.OutputDetail{
+		KodeProvinsi:     "51",
+		KodeKabupaten:    "03",
+		KodeKecamatan:    "03",
+		KodeDesa:         "03.2001.",
+		KodeKabupatenRaw: "03",
+		KodeKecamatanRaw: "03",
+		KodeDesaRaw:      "03.2001.",
+		NamaKabupaten:    "  KABUPATEN BADUNG ",
+		NamaDesa:         "dalung",
+		KodeSumber:       "DDS",
+		IDKegiatan:       " K1 ",
+	}
+}
+
+func apply[T any](t *testing.T, transforms []Transform[T], records []T) []T {
+	t.Helper()
+	for _, tr := range transforms {
+		records = tr(records)
+	}
+	return records
+}
+
+func TestCodesStep(t *testing.T) {
+	tests := []struct {
+		arg           string
+		kab, kec, des string
+	}{
+		{"dotted", "51.03", "51.03.03", "51.03.03.2001"},
+		{"compact", "5103", "510303", "5103032001"},
+		{"raw", "03", "03", "03.2001."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			tr, err := codesStep(nil)(tt.arg, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Dua kali untuk memastikan step idempoten.
+			got := apply(t, []Transform[domain.OutputDetail]{tr, tr}, []domain.OutputDetail{sampleDetail()})[0]
+			if got.KodeKabupaten != tt.kab || got.KodeKecamatan != tt.kec || got.KodeDesa != tt.des {
+				t.Errorf("codes:%s = %s %s %s, want %s %s %s", tt.arg,
+					got.KodeKabupaten, got.KodeKecamatan, got.KodeDesa, tt.kab, tt.kec, tt.des)
+			}
+			if got.KodeDesaRaw != "03.2001." {
+				t.Errorf("codes:%s changed kd_desa_raw to %q", tt.arg, got.KodeDesaRaw)
+			}
+		})
+	}
+	if _, err := codesStep(nil)("bps", nil); err == nil {
+		t.Error("codes:bps: want error")
+	}
+}
+
+func TestCodesStepLeavesInvalidCodes(t *testing.T) {
+	d := sampleDetail()
+	d.KodeProvinsi = "x"
+	var buf bytes.Buffer
+	tr, _ := codesStep(log.New(&buf, "", 0))("dotted", nil)
+	got := tr([]domain.OutputDetail{d})[0]
+	if got.KodeDesa != "03.2001." {
+		t.Errorf("invalid record kd_desa = %q, want unchanged", got.KodeDesa)
+	}
+	if !strings.Contains(buf.String(), "WARNING: kode wilayah") {
+		t.Errorf("warning not written to the given logger, got %q", buf.String())
+	}
+}
+
+func TestTitleCase(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"  KABUPATEN BADUNG ", "Kabupaten Badung"},
+		{"ÉLISE BALI", "Élise Bali"},
+		{"ömer çelik", "Ömer Çelik"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		got := titleCase(tt.in)
+		if got != tt.want || !utf8.ValidString(got) {
+			t.Errorf("titleCase(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestGenericSteps(t *testing.T) {
+	mappings := map[string]string{"kode_sumber.DDS": "DD", "kode_sumber.ADD": "AD"}
+	tests := []struct {
+		step  string
+		check func(d domain.OutputDetail) bool
+	}{
+		{"trim", func(d domain.OutputDetail) bool {
+			return d.NamaKabupaten == "KABUPATEN BADUNG" && d.IDKegiatan == "K1"
+		}},
+		{"case:upper", func(d domain.OutputDetail) bool { return d.NamaDesa == "DALUNG" }},
+		{"case:lower", func(d domain.OutputDetail) bool { return d.NamaKabupaten == "  kabupaten badung " }},
+		{"case:title", func(d domain.OutputDetail) bool {
+			return d.NamaKabupaten == "Kabupaten Badung" && d.IDKegiatan == " K1 "
+		}},
+		{"map:kode_sumber", func(d domain.OutputDetail) bool { return d.KodeSumber == "DD" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.step, func(t *testing.T) {
+			transforms, err := BuildTransforms([]string{tt.step}, mappings, outputDetailSteps(nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := apply(t, transforms, []domain.OutputDetail{sampleDetail()})[0]
+			if !tt.check(got) {
+				t.Errorf("%s produced %+v", tt.step, got)
+			}
+		})
+	}
+}
+
+func TestTrimKeepsRawColumns(t *testing.T) {
+	d := sampleDetail()
+	d.KodeDesaRaw = " 03.2001. "
+	tr, _ := trimStep[domain.OutputDetail]("", nil)
+	if got := tr([]domain.OutputDetail{d})[0]; got.KodeDesaRaw != " 03.2001. " {
+		t.Errorf("trim changed kd_desa_raw to %q", got.KodeDesaRaw)
+	}
+}
+
+func TestBuildTransformsErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		steps    []string
+		mappings map[string]string
+	}{
+		{"unknown step", []string{"nope"}, nil},
+		{"trim with argument", []string{"trim:x"}, nil},
+		{"bad case", []string{"case:camel"}, nil},
+		{"map without column", []string{"map"}, nil},
+		{"map unknown column", []string{"map:nope"}, map[string]string{"nope.a": "b"}},
+		{"map non-string column", []string{"map:pagu"}, map[string]string{"pagu.1": "2"}},
+		{"map without mappings", []string{"map:kode_sumber"}, nil},
+		{"bad codes", []string{"codes:bps"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := BuildTransforms(tt.steps, tt.mappings, outputDetailSteps(nil)); err == nil {
+				t.Errorf("BuildTransforms(%v): want error", tt.steps)
+			}
+		})
+	}
+}
+
+func TestOutputDetailProfiles(t *testing.T) {
+	tests := []struct {
+		profile string
+		kdDesa  string
+		nama    string
+	}{
+		{"default", "51.03.03.2001", "  KABUPATEN BADUNG "},
+		{"raw", "03.2001.", "KABUPATEN BADUNG"},
+		{"compact", "5103032001", "KABUPATEN BADUNG"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.profile, func(t *testing.T) {
+			transforms, err := OutputDetailTransforms(tt.profile, nil, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := apply(t, transforms, []domain.OutputDetail{sampleDetail()})[0]
+			if got.KodeDesa != tt.kdDesa || got.NamaKabupaten != tt.nama {
+				t.Errorf("profile %s: kd_desa %q nama %q, want %q %q", tt.profile, got.KodeDesa, got.NamaKabupaten, tt.kdDesa, tt.nama)
+			}
+		})
+	}
+
+	if _, err := OutputDetailTransforms("bps", nil, nil, nil); err == nil {
+		t.Error("profile bps: want error")
+	}
+	// steps menggantikan profil.
+	transforms, err := OutputDetailTransforms("nope", []string{"codes:compact"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := apply(t, transforms, []domain.OutputDetail{sampleDetail()}); got[0].KodeKabupaten != "5103" {
+		t.Errorf("steps override: kd_kab %q, want 5103", got[0].KodeKabupaten)
+	}
+}
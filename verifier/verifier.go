@@ -0,0 +1,235 @@
+package verifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/aryadiwwt/synctodb/domain"
+	"github.com/aryadiwwt/synctodb/fetcher"
+	"github.com/aryadiwwt/synctodb/storer"
+)
+
+// SourceAPI adalah satu-satunya sumber pembanding: data diambil ulang dari
+// API. Verifikasi terhadap arsip respons mentah tidak didukung karena arsip
+// tersebut tidak disimpan.
+const SourceAPI = "api"
+
+// Status hasil verifikasi satu kabupaten/kota.
+const (
+	StatusOK       = "ok"
+	StatusMismatch = "mismatch"
+	StatusError    = "error"
+)
+
+// Thresholds adalah selisih yang masih ditoleransi sebelum sebuah wilayah
+// dianggap tidak cocok.
+type Thresholds struct {
+	MaxCountDiff  int64          `json:"max_count_diff"`
+	MaxAmountDiff domain.Decimal `json:"max_amount_diff"`
+}
+
+// Diff adalah selisih satu kolom antara API dan database (api - db).
+type Diff struct {
+	Field    string         `json:"field"`
+	API      domain.Decimal `json:"api"`
+	DB       domain.Decimal `json:"db"`
+	Diff     domain.Decimal `json:"diff"`
+	Exceeded bool           `json:"exceeded"`
+}
+
+// Result adalah hasil verifikasi satu kabupaten/kota. Diffs hanya memuat
+// kolom yang selisihnya tidak nol.
+type Result struct {
+	KodeProvinsi  string         `json:"kd_prov"`
+	KodeKabupaten string         `json:"kd_kab"`
+	Status        string         `json:"status"`
+	API           *domain.Totals `json:"api,omitempty"`
+	DB            *domain.Totals `json:"db,omitempty"`
+	Diffs         []Diff         `json:"diffs,omitempty"`
+	Error         string         `json:"error,omitempty"`
+}
+
+// Summary menghitung hasil per status.
+type Summary struct {
+	Checked  int `json:"checked"`
+	OK       int `json:"ok"`
+	Mismatch int `json:"mismatch"`
+	Errors   int `json:"errors"`
+}
+
+// Report adalah laporan rekonsiliasi yang bisa dibaca mesin.
+type Report struct {
+	GeneratedAt time.Time  `json:"generated_at"`
+	Tahun       string     `json:"tahun"`
+	Source      string     `json:"source"` // selalu SourceAPI
+	Thresholds  Thresholds `json:"thresholds"`
+	Summary     Summary    `json:"summary"`
+	Results     []Result   `json:"results"`
+}
+
+// Failed melaporkan apakah ada wilayah yang melebihi threshold atau gagal
+// diperiksa.
+func (r *Report) Failed() bool {
+	return r.Summary.Mismatch > 0 || r.Summary.Errors > 0
+}
+
+// WriteJSON menulis laporan sebagai JSON yang terindentasi.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// Verifier membandingkan total dari API (fetch baru) dengan total yang
+// tersimpan di database, per (tahun, kd_prov, kd_kab).
+type Verifier struct {
+	fetcher    fetcher.Fetcher
+	totals     storer.TotalsReader
+	wilayah    storer.WilayahSource
+	tahun      string
+	thresholds Thresholds
+	log        *log.Logger
+	delay      time.Duration // jeda antar kabupaten
+}
+
+func NewVerifier(f fetcher.Fetcher, t storer.TotalsReader, w storer.WilayahSource, tahun int, th Thresholds, l *log.Logger, delay time.Duration) *Verifier {
+	return &Verifier{
+		fetcher:    f,
+		totals:     t,
+		wilayah:    w,
+		tahun:      fmt.Sprint(tahun),
+		thresholds: th,
+		log:        l,
+		delay:      delay,
+	}
+}
+
+// Verify memeriksa semua kabupaten/kota di provinsi yang diminta. Error per
+// wilayah dicatat di laporan, bukan menghentikan proses; error hanya
+// dikembalikan jika daftar wilayah tidak bisa diambil.
+func (v *Verifier) Verify(ctx context.Context, kodeProvinsi []string) (*Report, error) {
+	daftarWilayah, err := v.wilayah.GetWilayahByProvinsi(ctx, kodeProvinsi)
+	if err != nil {
+		return nil, fmt.Errorf("gagal mendapatkan daftar wilayah: %w", err)
+	}
+
+	report := &Report{
+		GeneratedAt: time.Now().UTC(),
+		Tahun:       v.tahun,
+		Source:      SourceAPI,
+		Thresholds:  v.thresholds,
+		Results:     []Result{},
+	}
+
+	for i, wilayah := range daftarWilayah {
+		if i > 0 && v.delay > 0 {
+			timer := time.NewTimer(v.delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, ctx.Err()
+			case <-timer.C:
+			}
+		}
+
+		v.log.Printf("Verifikasi Provinsi: %s, Kabupaten: %s", wilayah.KodeProvinsi, wilayah.KodeKabupaten)
+		result := v.verifyWilayah(ctx, wilayah)
+		report.Results = append(report.Results, result)
+
+		report.Summary.Checked++
+		switch result.Status {
+		case StatusOK:
+			report.Summary.OK++
+		case StatusMismatch:
+			report.Summary.Mismatch++
+			v.log.Printf("MISMATCH Prov %s Kab %s: %d kolom melebihi threshold", wilayah.KodeProvinsi, wilayah.KodeKabupaten, countExceeded(result.Diffs))
+		case StatusError:
+			report.Summary.Errors++
+			v.log.Printf("ERROR verifikasi Prov %s Kab %s: %s", wilayah.KodeProvinsi, wilayah.KodeKabupaten, result.Error)
+		}
+	}
+
+	return report, nil
+}
+
+func (v *Verifier) verifyWilayah(ctx context.Context, w storer.Wilayah) Result {
+	result := Result{KodeProvinsi: w.KodeProvinsi, KodeKabupaten: w.KodeKabupaten}
+	fail := func(err error) Result {
+		result.Status = StatusError
+		result.Error = err.Error()
+		return result
+	}
+
+	kabupaten, err := domain.ParseKodeWilayah(w.KodeProvinsi + "." + w.KodeKabupaten)
+	if err != nil {
+		return fail(err)
+	}
+
+	details, err := v.fetcher.FetchOutputDetails(ctx, w.KodeProvinsi, w.KodeKabupaten)
+	if err != nil {
+		return fail(fmt.Errorf("fetch: %w", err))
+	}
+	var api domain.Totals
+	for _, d := range details {
+		api.Add(d)
+	}
+
+	db, err := v.totals.OutputDetailTotals(ctx, v.tahun, kabupaten)
+	if err != nil {
+		return fail(fmt.Errorf("database: %w", err))
+	}
+
+	result.API, result.DB = &api, &db
+	result.Diffs = compare(api, db, v.thresholds)
+	result.Status = StatusOK
+	if countExceeded(result.Diffs) > 0 {
+		result.Status = StatusMismatch
+	}
+	return result
+}
+
+// compare mengembalikan selisih setiap kolom yang tidak nol.
+func compare(api, db domain.Totals, th Thresholds) []Diff {
+	var diffs []Diff
+
+	count := domain.DecimalFromInt(api.Count - db.Count)
+	if !count.IsZero() {
+		diffs = append(diffs, Diff{
+			Field:    "count",
+			API:      domain.DecimalFromInt(api.Count),
+			DB:       domain.DecimalFromInt(db.Count),
+			Diff:     count,
+			Exceeded: count.Abs().Cmp(domain.DecimalFromInt(th.MaxCountDiff)) > 0,
+		})
+	}
+
+	dbAmounts := db.Amounts()
+	for i, a := range api.Amounts() {
+		diff := a.Amount.Sub(dbAmounts[i].Amount)
+		if diff.IsZero() {
+			continue
+		}
+		diffs = append(diffs, Diff{
+			Field:    a.Name,
+			API:      a.Amount,
+			DB:       dbAmounts[i].Amount,
+			Diff:     diff,
+			Exceeded: diff.Abs().Cmp(th.MaxAmountDiff) > 0,
+		})
+	}
+	return diffs
+}
+
+func countExceeded(diffs []Diff) int {
+	n := 0
+	for _, d := range diffs {
+		if d.Exceeded {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,175 @@
+package verifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"testing"
+
+	"github.com/aryadiwwt/synctodb/domain"
+	"github.com/aryadiwwt/synctodb/storer"
+)
+
+func dec(t *testing.T, s string) domain.Decimal {
+	t.Helper()
+	d, err := domain.ParseDecimal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestCompareThresholds(t *testing.T) {
+	th := Thresholds{MaxCountDiff: 2, MaxAmountDiff: dec(t, "100.00")}
+	base := func() domain.Totals {
+		return domain.Totals{Count: 10, Pagu: dec(t, "5000.00"), Realisasi0: dec(t, "1000.00")}
+	}
+
+	tests := []struct {
+		name         string
+		db           func(domain.Totals) domain.Totals
+		wantDiffs    int
+		wantExceeded int
+	}{
+		{name: "tanpa selisih", db: func(d domain.Totals) domain.Totals { return d }},
+		{
+			name:      "selisih jumlah sama dengan threshold",
+			db:        func(d domain.Totals) domain.Totals { d.Count -= 2; return d },
+			wantDiffs: 1,
+		},
+		{
+			name:         "selisih jumlah di atas threshold",
+			db:           func(d domain.Totals) domain.Totals { d.Count -= 3; return d },
+			wantDiffs:    1,
+			wantExceeded: 1,
+		},
+		{
+			name:         "selisih jumlah negatif di atas threshold",
+			db:           func(d domain.Totals) domain.Totals { d.Count += 3; return d },
+			wantDiffs:    1,
+			wantExceeded: 1,
+		},
+		{
+			name:      "selisih nominal sama dengan threshold",
+			db:        func(d domain.Totals) domain.Totals { d.Pagu = dec(t, "4900.00"); return d },
+			wantDiffs: 1,
+		},
+		{
+			name:      "selisih nominal negatif sama dengan threshold",
+			db:        func(d domain.Totals) domain.Totals { d.Pagu = dec(t, "5100"); return d },
+			wantDiffs: 1,
+		},
+		{
+			name:         "selisih nominal di atas threshold",
+			db:           func(d domain.Totals) domain.Totals { d.Pagu = dec(t, "4899.99"); return d },
+			wantDiffs:    1,
+			wantExceeded: 1,
+		},
+		{
+			name:         "selisih nominal negatif di atas threshold",
+			db:           func(d domain.Totals) domain.Totals { d.Realisasi0 = dec(t, "1100.01"); return d },
+			wantDiffs:    1,
+			wantExceeded: 1,
+		},
+		{
+			name: "beberapa kolom",
+			db: func(d domain.Totals) domain.Totals {
+				d.Count--
+				d.Pagu = dec(t, "0")
+				d.Realisasi0 = dec(t, "1000.50")
+				return d
+			},
+			wantDiffs:    3,
+			wantExceeded: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs := compare(base(), tt.db(base()), th)
+			if len(diffs) != tt.wantDiffs {
+				t.Errorf("compare() menghasilkan %d diff, want %d: %+v", len(diffs), tt.wantDiffs, diffs)
+			}
+			if got := countExceeded(diffs); got != tt.wantExceeded {
+				t.Errorf("countExceeded() = %d, want %d: %+v", got, tt.wantExceeded, diffs)
+			}
+		})
+	}
+}
+
+func TestCompareDiffSign(t *testing.T) {
+	diffs := compare(domain.Totals{Count: 5}, domain.Totals{Count: 8}, Thresholds{})
+	if len(diffs) != 1 || diffs[0].Diff.String() != "-3" || !diffs[0].Exceeded {
+		t.Errorf("diff = %+v, want api - db = -3 dan melebihi threshold 0", diffs)
+	}
+}
+
+type fakeFetcher struct {
+	details map[string][]domain.OutputDetail
+}
+
+func (f fakeFetcher) FetchRecords(context.Context, string, string, string) ([]json.RawMessage, error) {
+	return nil, errors.New("not used")
+}
+
+func (f fakeFetcher) FetchOutputDetails(_ context.Context, kdProv, kdKab string) ([]domain.OutputDetail, error) {
+	d, ok := f.details[kdProv+"."+kdKab]
+	if !ok {
+		return nil, errors.New("status 500")
+	}
+	return d, nil
+}
+
+type fakeTotals map[string]domain.Totals
+
+func (f fakeTotals) OutputDetailTotals(_ context.Context, _ string, kab domain.KodeWilayah) (domain.Totals, error) {
+	return f[kab.Dotted()], nil
+}
+
+type fakeWilayah []storer.Wilayah
+
+func (w fakeWilayah) GetWilayahByProvinsi(context.Context, []string) ([]storer.Wilayah, error) {
+	return w, nil
+}
+
+func TestVerifyReport(t *testing.T) {
+	detail := domain.OutputDetail{Pagu: dec(t, "100.00")}
+	tests := []struct {
+		name       string
+		dbCount    int64
+		fetchFails bool
+		wantStatus string
+		wantFailed bool
+	}{
+		{name: "cocok", dbCount: 2, wantStatus: StatusOK},
+		{name: "dalam threshold", dbCount: 1, wantStatus: StatusOK},
+		{name: "melebihi threshold", dbCount: 0, wantStatus: StatusMismatch, wantFailed: true},
+		{name: "fetch gagal", fetchFails: true, wantStatus: StatusError, wantFailed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := fakeFetcher{details: map[string][]domain.OutputDetail{"51.03": {detail, detail}}}
+			if tt.fetchFails {
+				f.details = nil
+			}
+			db := fakeTotals{"51.03": {Count: tt.dbCount, Pagu: dec(t, "200.00")}}
+			v := NewVerifier(f, db, fakeWilayah{{KodeProvinsi: "51", KodeKabupaten: "03"}}, 2024,
+				Thresholds{MaxCountDiff: 1}, log.New(io.Discard, "", 0), 0)
+
+			report, err := v.Verify(context.Background(), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := report.Results[0].Status; got != tt.wantStatus {
+				t.Errorf("status = %s, want %s", got, tt.wantStatus)
+			}
+			if report.Failed() != tt.wantFailed {
+				t.Errorf("Failed() = %v, want %v", report.Failed(), tt.wantFailed)
+			}
+			if report.Source != SourceAPI || report.Tahun != "2024" || report.Summary.Checked != 1 {
+				t.Errorf("report = %+v", report)
+			}
+		})
+	}
+}
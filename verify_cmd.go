@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/aryadiwwt/synctodb/domain"
+	"github.com/aryadiwwt/synctodb/redact"
+	"github.com/aryadiwwt/synctodb/storer"
+	"github.com/aryadiwwt/synctodb/verifier"
+)
+
+// exitVerifyFailed dipakai jika ada wilayah yang melebihi threshold atau
+// gagal diverifikasi, agar bisa dibedakan dari error biasa (1) di CI/cron.
+const exitVerifyFailed = 3
+
+const verifyUsage = `Penggunaan:
+  verify [-config file] [-set key=value] [-prov 51,52] [-report verify.json]
+
+Membandingkan jumlah record dan total nilai uang per kabupaten antara fetch
+baru dari API dan siskeudes_detail_output. Pembanding selalu fetch baru;
+verifikasi terhadap arsip respons mentah tidak didukung karena aplikasi
+tidak menyimpan arsip tersebut.
+
+Flag:`
+
+// runVerify membandingkan total per kabupaten antara API dan database lalu
+// menulis laporan JSON, dan mengembalikan exit code.
+func runVerify(logger *log.Logger, redactor *redact.Redactor, args []string) int {
+	// Hasil bisa ditulis ke stdout, jadi log dipindah ke stderr.
+	logger.SetOutput(redactor.Writer(os.Stderr))
+
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	configPath, overrides := configFlags(fs)
+	provinsiPtr := fs.String("prov", "", "Daftar kode provinsi yang dipisahkan koma (contoh: 11,12,51)")
+	reportPtr := fs.String("report", "", "Path file laporan JSON (default: verify.report atau stdout)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), verifyUsage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	flagOverrides := *overrides
+	if *provinsiPtr != "" {
+		flagOverrides = append(flagOverrides, "sync.provinsi="+*provinsiPtr)
+	}
+	if *reportPtr != "" {
+		flagOverrides = append(flagOverrides, "verify.report="+*reportPtr)
+	}
+	cfg := loadConfig(logger, redactor, *configPath, flagOverrides)
+
+	db := openDatabase(logger, redactor, cfg)
+	defer db.Close()
+
+	wilayahSource, err := newWilayahSource(cfg.Wilayah, db, redactor)
+	if err != nil {
+		logger.Fatalf("FATAL: %v", err)
+	}
+
+	dataFetcher, err := newFetcher(cfg, redactor)
+	if err != nil {
+		logger.Fatalf("FATAL: %v", err)
+	}
+
+	// Validasi konfigurasi sudah memastikan nilainya desimal.
+	maxAmountDiff, _ := domain.ParseDecimal(cfg.Verify.MaxAmountDiff)
+	v := verifier.NewVerifier(
+		dataFetcher,
+		storer.NewTotalsReader(db, redactor),
+		wilayahSource,
+		cfg.API.Tahun,
+		verifier.Thresholds{MaxCountDiff: cfg.Verify.MaxCountDiff, MaxAmountDiff: maxAmountDiff},
+		logger,
+		cfg.Sync.Delay,
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Sync.RunTimeout)
+	defer cancel()
+
+	report, err := v.Verify(ctx, cfg.Sync.Provinsi)
+	if err != nil {
+		logger.Fatalf("Verifikasi gagal: %v", err)
+	}
+
+	out := os.Stdout
+	if cfg.Verify.Report != "" {
+		f, err := os.Create(cfg.Verify.Report)
+		if err != nil {
+			logger.Fatalf("FATAL: gagal membuat file laporan: %v", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := report.WriteJSON(out); err != nil {
+		logger.Fatalf("FATAL: gagal menulis laporan: %v", err)
+	}
+
+	logger.Printf("Verifikasi selesai: %d diperiksa, %d cocok, %d tidak cocok, %d error.",
+		report.Summary.Checked, report.Summary.OK, report.Summary.Mismatch, report.Summary.Errors)
+	if report.Failed() {
+		return exitVerifyFailed
+	}
+	return 0
+}